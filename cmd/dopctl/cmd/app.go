@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// application is the application representation returned by the server
+type application struct {
+	Name         string             `json:"name"`
+	Namespace    string             `json:"namespace"`
+	Labels       map[string]string  `json:"labels,omitempty"`
+	Status       string             `json:"status"`
+	SyncStatus   string             `json:"syncStatus"`
+	SyncPolicy   applicationPolicy  `json:"syncPolicy"`
+	DependsOn    []string           `json:"dependsOn,omitempty"`
+	Source       *applicationSource `json:"source,omitempty"`
+	TargetCommit string             `json:"targetCommit,omitempty"`
+	SyncedCommit string             `json:"syncedCommit,omitempty"`
+	// ResourceVersion changes whenever the application is updated
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// applicationPolicy is the sync policy of an application
+type applicationPolicy struct {
+	AutoSync bool `json:"autoSync"`
+	SelfHeal bool `json:"selfHeal"`
+	Prune    bool `json:"prune"`
+}
+
+// applicationSource is the Git source of an application
+type applicationSource struct {
+	RepoURL  string          `json:"repoURL,omitempty"`
+	Revision string          `json:"revision,omitempty"`
+	Path     string          `json:"path,omitempty"`
+	Helm     *helmSource     `json:"helm,omitempty"`
+	Template *templateSource `json:"template,omitempty"`
+}
+
+// helmSource configures how the Helm chart of an application is rendered
+type helmSource struct {
+	ReleaseName string   `json:"releaseName,omitempty"`
+	ValueFiles  []string `json:"valueFiles,omitempty"`
+	Values      string   `json:"values,omitempty"`
+}
+
+// templateSource selects the catalog template an application is rendered from
+type templateSource struct {
+	Name       string            `json:"name"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// appCmd represents the app command
+var appCmd = &cobra.Command{
+	Use:     "app",
+	Aliases: []string{"application"},
+	Short:   "Manage applications",
+	Long: `Manage applications deployed through DevOps Bridge.
+These commands talk to the DevOps Bridge server configured with --server.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("Use one of the app subcommands. Run 'dopctl app --help' for usage.")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(appCmd)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/strvals"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	createNamespace string
+	createRepo      string
+	createPath      string
+	createRevision  string
+	createValues    []string
+	createTemplate  string
+	createParams    []string
+	createLabels    map[string]string
+	createDependsOn []string
+	createPolicy    applicationPolicy
+)
+
+// templateApplicationRequest is the body of creating an application from a
+// template
+type templateApplicationRequest struct {
+	Name       string            `json:"name"`
+	Namespace  string            `json:"namespace"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Parameters map[string]string `json:"parameters"`
+	SyncPolicy applicationPolicy `json:"syncPolicy"`
+	DependsOn  []string          `json:"dependsOn,omitempty"`
+}
+
+// appCreateCmd represents the app create command
+var appCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an application",
+	Long: `Create an application. With --repo, its desired state is read from the
+plain YAML manifests or the Helm chart in --path of a Git repository at
+--revision. With --template, its manifests are rendered from a template of
+the catalog with the parameters given with --set, e.g.
+
+  dopctl app create guestbook --repo https://github.com/example/apps.git \
+    --path guestbook --revision main --auto-sync
+  dopctl app create bridge --repo https://github.com/sysintelligent/devops-bridge.git \
+    --path dist/helm/devops-bridge --values values.yaml --set image.tag=v0.2.0
+  dopctl app create shop --template web-service --set image=nginx:1.27 --set replicas=2
+
+Run 'dopctl template list' for the available templates.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if createTemplate != "" {
+			return createFromTemplate(args[0])
+		}
+		if len(createParams) > 0 && createRepo == "" {
+			return errors.New("--set requires --template or --repo")
+		}
+
+		app := application{
+			Name:       args[0],
+			Namespace:  createNamespace,
+			Labels:     createLabels,
+			SyncPolicy: createPolicy,
+			DependsOn:  createDependsOn,
+		}
+		if createRepo != "" {
+			app.Source = &applicationSource{
+				RepoURL:  createRepo,
+				Revision: createRevision,
+				Path:     createPath,
+			}
+			if len(createValues) > 0 || len(createParams) > 0 {
+				values, err := helmValues(createParams)
+				if err != nil {
+					return err
+				}
+				app.Source.Helm = &helmSource{ValueFiles: createValues, Values: values}
+			}
+		} else if createPath != "" || createRevision != "" || len(createValues) > 0 {
+			return errors.New("--path, --revision and --values require --repo")
+		}
+
+		var created application
+		if err := newAPIClient().do(http.MethodPost, "/applications", app, &created); err != nil {
+			return err
+		}
+		fmt.Printf("Application %s created in namespace %s\n", created.Name, created.Namespace)
+		return nil
+	},
+}
+
+// helmValues converts --set overrides in Helm's key=value syntax, e.g.
+// image.tag=1.2, into a YAML document of values
+func helmValues(overrides []string) (string, error) {
+	if len(overrides) == 0 {
+		return "", nil
+	}
+	values := map[string]interface{}{}
+	for _, override := range overrides {
+		if err := strvals.ParseInto(override, values); err != nil {
+			return "", fmt.Errorf("invalid value %q: %w", override, err)
+		}
+	}
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// createFromTemplate creates an application from the template given with
+// --template
+func createFromTemplate(name string) error {
+	if createRepo != "" || createPath != "" || createRevision != "" || len(createValues) > 0 {
+		return errors.New("--template cannot be combined with --repo, --path, --revision or --values")
+	}
+	req := templateApplicationRequest{
+		Name:       name,
+		Namespace:  createNamespace,
+		Labels:     createLabels,
+		Parameters: map[string]string{},
+		SyncPolicy: createPolicy,
+		DependsOn:  createDependsOn,
+	}
+	for _, param := range createParams {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid parameter %q, expected key=value", param)
+		}
+		req.Parameters[key] = value
+	}
+
+	var created application
+	path := "/templates/" + url.PathEscape(createTemplate) + "/applications"
+	if err := newAPIClient().do(http.MethodPost, path, req, &created); err != nil {
+		return err
+	}
+	fmt.Printf("Application %s created in namespace %s from template %s\n", created.Name, created.Namespace, createTemplate)
+	return nil
+}
+
+func init() {
+	appCmd.AddCommand(appCreateCmd)
+
+	appCreateCmd.Flags().StringVarP(&createNamespace, "namespace", "n", "default", "Namespace to deploy the application to")
+	appCreateCmd.Flags().StringToStringVarP(&createLabels, "label", "l", nil, "Label of the application as key=value, e.g. team=payments (can be repeated)")
+	appCreateCmd.Flags().StringSliceVar(&createDependsOn, "depends-on", nil, "Application that must be healthy before this one is synced (can be repeated)")
+	appCreateCmd.Flags().StringVar(&createRepo, "repo", "", "URL of the Git repository holding the manifests")
+	appCreateCmd.Flags().StringVar(&createPath, "path", "", "Directory of the manifests within the repository (default: the root)")
+	appCreateCmd.Flags().StringVar(&createRevision, "revision", "", "Branch, tag or commit to deploy (default: HEAD)")
+	appCreateCmd.Flags().StringSliceVar(&createValues, "values", nil, "Values file of a Helm chart, relative to the chart (can be repeated)")
+	appCreateCmd.Flags().StringVar(&createTemplate, "template", "", "Catalog template to render the manifests from")
+	appCreateCmd.Flags().StringArrayVar(&createParams, "set", nil, "Template parameter, or Helm value with --repo, as key=value (can be repeated)")
+	appCreateCmd.Flags().BoolVar(&createPolicy.AutoSync, "auto-sync", false, "Sync the application when its desired state changes")
+	appCreateCmd.Flags().BoolVar(&createPolicy.SelfHeal, "self-heal", false, "Also sync when the live state drifts, requires --auto-sync")
+	appCreateCmd.Flags().BoolVar(&createPolicy.Prune, "prune", false, "Delete objects that are no longer desired when syncing, requires --auto-sync")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var eventsType string
+
+// event is the event representation returned by the server
+type event struct {
+	Type       string    `json:"type"`
+	Reason     string    `json:"reason"`
+	Message    string    `json:"message"`
+	ObjectKind string    `json:"objectKind"`
+	ObjectName string    `json:"objectName"`
+	Count      int32     `json:"count"`
+	LastSeen   time.Time `json:"lastSeen"`
+}
+
+// appEventsCmd represents the app events command
+var appEventsCmd = &cobra.Command{
+	Use:   "events <name>",
+	Short: "Print the Kubernetes events of an application",
+	Long: `Print the Kubernetes events of all objects belonging to an application,
+newest first. Repeated events are merged into a single line.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := url.Values{}
+		if eventsType != "" {
+			query.Set("type", eventsType)
+		}
+
+		var events []event
+		if err := newAPIClient().do(http.MethodGet, "/applications/"+url.PathEscape(args[0])+"/events?"+query.Encode(), nil, &events); err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "LAST SEEN\tTYPE\tREASON\tOBJECT\tCOUNT\tMESSAGE")
+		for _, e := range events {
+			fmt.Fprintf(w, "%s ago\t%s\t%s\t%s/%s\t%d\t%s\n",
+				time.Since(e.LastSeen).Round(time.Second), e.Type, e.Reason,
+				e.ObjectKind, e.ObjectName, e.Count, e.Message)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	appCmd.AddCommand(appEventsCmd)
+
+	appEventsCmd.Flags().StringVarP(&eventsType, "type", "t", "", "Only print events of this type (Normal, Warning)")
+}
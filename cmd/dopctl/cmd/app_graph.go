@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// dependencyGraph is the dependency graph returned by the server
+type dependencyGraph struct {
+	Nodes []struct {
+		Name       string   `json:"name"`
+		Namespace  string   `json:"namespace"`
+		Status     string   `json:"status"`
+		SyncStatus string   `json:"syncStatus"`
+		Wave       int      `json:"wave"`
+		DependsOn  []string `json:"dependsOn"`
+		BlockedBy  []string `json:"blockedBy"`
+	} `json:"nodes"`
+}
+
+// appGraphCmd represents the app graph command
+var appGraphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Show how applications depend on each other",
+	Long: `Show the applications in the order they are synced: each wave only once
+the applications it depends on are healthy. BLOCKED BY lists the
+dependencies that are missing or not healthy yet.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var graph dependencyGraph
+		if err := newAPIClient().do(http.MethodGet, "/applications:graph", nil, &graph); err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "WAVE\tNAME\tNAMESPACE\tSTATUS\tSYNC\tDEPENDS ON\tBLOCKED BY")
+		for _, node := range graph.Nodes {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", node.Wave, node.Name, node.Namespace, node.Status,
+				node.SyncStatus, orNone(node.DependsOn), orNone(node.BlockedBy))
+		}
+		return w.Flush()
+	},
+}
+
+// orNone joins names, or returns - if there are none
+func orNone(names []string) string {
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ",")
+}
+
+func init() {
+	appCmd.AddCommand(appGraphCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var historyRevision int
+
+// revision is the revision representation returned by the server
+type revision struct {
+	Number     int       `json:"number"`
+	Reason     string    `json:"reason"`
+	Message    string    `json:"message"`
+	Author     string    `json:"author"`
+	AuthorName string    `json:"authorName"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// revisionDiff is the revision diff representation returned by the server
+type revisionDiff struct {
+	From    int `json:"from"`
+	To      int `json:"to"`
+	Changes []struct {
+		Field string      `json:"field"`
+		From  interface{} `json:"from"`
+		To    interface{} `json:"to"`
+	} `json:"changes"`
+}
+
+// appHistoryCmd represents the app history command
+var appHistoryCmd = &cobra.Command{
+	Use:   "history <name>",
+	Short: "Print the revision history of an application",
+	Long: `Print the revision history of an application, newest first.
+With --revision, print what changed in that revision instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		base := "/applications/" + url.PathEscape(args[0]) + "/revisions"
+		client := newAPIClient()
+
+		if historyRevision > 0 {
+			var diff revisionDiff
+			if err := client.do(http.MethodGet, base+"/"+strconv.Itoa(historyRevision)+"/diff", nil, &diff); err != nil {
+				return err
+			}
+			fmt.Printf("Changes from revision %d to %d:\n", diff.From, diff.To)
+			for _, change := range diff.Changes {
+				from, _ := json.Marshal(change.From)
+				to, _ := json.Marshal(change.To)
+				fmt.Printf("  %s: %s -> %s\n", change.Field, from, to)
+			}
+			return nil
+		}
+
+		var revisions []revision
+		if err := client.do(http.MethodGet, base, nil, &revisions); err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "REVISION\tDATE\tAUTHOR\tREASON\tMESSAGE")
+		for _, rev := range revisions {
+			author := rev.Author
+			if rev.AuthorName != "" {
+				author = rev.AuthorName
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", rev.Number,
+				rev.CreatedAt.Local().Format(time.DateTime), author, rev.Reason, rev.Message)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	appCmd.AddCommand(appHistoryCmd)
+
+	appHistoryCmd.Flags().IntVarP(&historyRevision, "revision", "r", 0, "Print the changes made in this revision")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsContainer string
+	logsSince     time.Duration
+	logsTail      int64
+	logsFollow    bool
+	logsNoPrefix  bool
+)
+
+// appLogsCmd represents the app logs command
+var appLogsCmd = &cobra.Command{
+	Use:   "logs <name>",
+	Short: "Print the logs of an application",
+	Long: `Print the logs of all pods belonging to an application.
+Each line is prefixed with the pod and container it came from.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := url.Values{}
+		if logsContainer != "" {
+			query.Set("container", logsContainer)
+		}
+		if logsSince > 0 {
+			query.Set("since", logsSince.String())
+		}
+		if logsTail > 0 {
+			query.Set("tailLines", strconv.FormatInt(logsTail, 10))
+		}
+		query.Set("follow", strconv.FormatBool(logsFollow))
+		query.Set("prefix", strconv.FormatBool(!logsNoPrefix))
+
+		// Streams may stay open indefinitely
+		client := newAPIClient()
+		client.httpClient.Timeout = 0
+
+		resp, err := client.send(http.MethodGet, "/applications/"+url.PathEscape(args[0])+"/logs?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		_, err = io.Copy(os.Stdout, resp.Body)
+		return err
+	},
+}
+
+func init() {
+	appCmd.AddCommand(appLogsCmd)
+
+	appLogsCmd.Flags().StringVarP(&logsContainer, "container", "c", "", "Only print logs of containers with this name")
+	appLogsCmd.Flags().DurationVar(&logsSince, "since", 0, "Only print logs newer than this duration, e.g. 10m")
+	appLogsCmd.Flags().Int64Var(&logsTail, "tail", 0, "Number of recent lines to print per container")
+	appLogsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep streaming new log lines")
+	appLogsCmd.Flags().BoolVar(&logsNoPrefix, "no-prefix", false, "Don't prefix lines with the pod and container name")
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// manifests is the rendered desired state returned by the server
+type manifests struct {
+	Commit  string                   `json:"commit"`
+	Objects []map[string]interface{} `json:"objects"`
+}
+
+// appManifestsCmd represents the app manifests command
+var appManifestsCmd = &cobra.Command{
+	Use:   "manifests <name>",
+	Short: "Print the rendered manifests of an application",
+	Long: `Print the objects rendered from the source of an application, plain
+manifests or a Helm chart, as YAML documents. These are the objects applied
+when the application is synced.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var out manifests
+		if err := newAPIClient().do(http.MethodGet, "/applications/"+url.PathEscape(args[0])+"/manifests", nil, &out); err != nil {
+			return err
+		}
+
+		fmt.Printf("# Rendered from commit %s\n", out.Commit)
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		for _, obj := range out.Objects {
+			if err := enc.Encode(obj); err != nil {
+				return err
+			}
+		}
+		return enc.Close()
+	},
+}
+
+func init() {
+	appCmd.AddCommand(appManifestsCmd)
+}
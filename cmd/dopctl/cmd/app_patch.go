@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	patchType    string
+	patchBody    string
+	patchFile    string
+	patchIfMatch string
+)
+
+// patchContentTypes maps the patch types to their media types
+var patchContentTypes = map[string]string{
+	"merge": "application/merge-patch+json",
+	"json":  "application/json-patch+json",
+}
+
+// appPatchCmd represents the app patch command
+var appPatchCmd = &cobra.Command{
+	Use:   "patch <name>",
+	Short: "Change some fields of an application",
+	Long: `Change some fields of an application with a JSON Merge Patch (--type merge,
+the default) or a JSON Patch (--type json), given as JSON or YAML, e.g.
+
+  dopctl app patch guestbook -p '{"syncPolicy": {"autoSync": true}}'
+  dopctl app patch guestbook --type json -p '[{"op": "replace", "path": "/source/revision", "value": "v2"}]'
+
+With --resource-version, the patch only applies if nobody changed the
+application since that version.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contentType, ok := patchContentTypes[patchType]
+		if !ok {
+			return fmt.Errorf("unknown patch type %q, expected merge or json", patchType)
+		}
+
+		patch := []byte(patchBody)
+		if patchFile != "" {
+			if patchBody != "" {
+				return errors.New("--patch and --patch-file are mutually exclusive")
+			}
+			data, err := os.ReadFile(patchFile)
+			if err != nil {
+				return err
+			}
+			patch = data
+		}
+		if len(bytes.TrimSpace(patch)) == 0 {
+			return errors.New("--patch or --patch-file is required")
+		}
+		// Accept YAML as well, which is a superset of JSON
+		patch, err := yaml.YAMLToJSON(patch)
+		if err != nil {
+			return fmt.Errorf("invalid patch: %w", err)
+		}
+
+		client := newAPIClient()
+		req, err := http.NewRequest(http.MethodPatch, client.baseURL+"/applications/"+url.PathEscape(args[0]), bytes.NewReader(patch))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
+		if patchIfMatch != "" {
+			req.Header.Set("If-Match", `"`+patchIfMatch+`"`)
+		}
+
+		resp, err := client.sendRequest(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		var app application
+		if err := json.NewDecoder(resp.Body).Decode(&app); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		fmt.Printf("Application %s patched (resource version %s)\n", app.Name, app.ResourceVersion)
+		return nil
+	},
+}
+
+func init() {
+	appCmd.AddCommand(appPatchCmd)
+
+	appPatchCmd.Flags().StringVar(&patchType, "type", "merge", "Type of the patch: merge or json")
+	appPatchCmd.Flags().StringVarP(&patchBody, "patch", "p", "", "The patch as JSON or YAML")
+	appPatchCmd.Flags().StringVar(&patchFile, "patch-file", "", "File holding the patch as JSON or YAML")
+	appPatchCmd.Flags().StringVar(&patchIfMatch, "resource-version", "", "Only patch this resource version of the application")
+}
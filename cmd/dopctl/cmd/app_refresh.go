@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+var refreshSync bool
+
+// appRefreshCmd represents the app refresh command
+var appRefreshCmd = &cobra.Command{
+	Use:   "refresh <name>",
+	Short: "Refresh the sync status of an application now",
+	Long: `Refresh the sync status of an application without waiting for the
+next periodic refresh. With --sync, an application that opted in to
+auto-sync is also synced if it is out of sync, which requires the sync
+permission.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var app application
+		path := "/applications/" + url.PathEscape(args[0]) + "/refresh"
+		if refreshSync {
+			path += "?sync=true"
+		}
+		if err := newAPIClient().do(http.MethodPost, path, nil, &app); err != nil {
+			return err
+		}
+		fmt.Printf("Application %s is %s and %s\n", app.Name, app.SyncStatus, app.Status)
+		if app.TargetCommit != "" {
+			fmt.Printf("Target commit: %s\nSynced commit: %s\n", app.TargetCommit, app.SyncedCommit)
+		}
+		return nil
+	},
+}
+
+func init() {
+	appCmd.AddCommand(appRefreshCmd)
+
+	appRefreshCmd.Flags().BoolVar(&refreshSync, "sync", false, "Also sync the application if it opted in to auto-sync")
+}
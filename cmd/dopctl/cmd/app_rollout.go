@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rolloutWorkload      string
+	rolloutReplicas      int32
+	rollbackTo           int64
+	rollbackAllWorkloads bool
+	rollbackMessage      string
+)
+
+// workloadResult is the per-workload action result returned by the server
+type workloadResult struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// appRestartCmd represents the app restart command
+var appRestartCmd = &cobra.Command{
+	Use:   "restart <name>",
+	Short: "Restart the pods of an application",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApplicationAction(args[0], "restart", map[string]interface{}{})
+	},
+}
+
+// appScaleCmd represents the app scale command
+var appScaleCmd = &cobra.Command{
+	Use:   "scale <name>",
+	Short: "Scale the workloads of an application",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApplicationAction(args[0], "scale", map[string]interface{}{
+			"replicas": rolloutReplicas,
+		})
+	},
+}
+
+// appRollbackCmd represents the app rollback command
+var appRollbackCmd = &cobra.Command{
+	Use:   "rollback <name>",
+	Short: "Roll an application back to a previous revision",
+	Long: `Roll an application back to a revision from 'dopctl app history'.
+With --all-workloads, roll back the Deployments and StatefulSets of the
+application to a previous Kubernetes rollout revision instead, or only
+the one given with --workload.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if rollbackAllWorkloads || rolloutWorkload != "" {
+			return runApplicationAction(args[0], "rollback", map[string]interface{}{
+				"toRevision": rollbackTo,
+			})
+		}
+		return runApplicationRollback(args[0])
+	},
+}
+
+// runApplicationRollback rolls an application back to a previous revision
+func runApplicationRollback(name string) error {
+	query := url.Values{}
+	if rollbackTo > 0 {
+		query.Set("to", strconv.FormatInt(rollbackTo, 10))
+	}
+
+	client := newAPIClient()
+	req, err := http.NewRequest(http.MethodPost, client.baseURL+"/applications/"+url.PathEscape(name)+"/rollback?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if rollbackMessage != "" {
+		req.Header.Set("X-Revision-Message", rollbackMessage)
+	}
+
+	resp, err := client.sendRequest(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	fmt.Printf("Application %s rolled back\n", name)
+	return nil
+}
+
+// runApplicationAction posts an action on the workloads of an application
+// and prints the result per workload
+func runApplicationAction(name, action string, body map[string]interface{}) error {
+	if rolloutWorkload != "" {
+		body["workload"] = rolloutWorkload
+	}
+
+	var results []workloadResult
+	path := "/applications/" + url.PathEscape(name) + "/actions/" + action
+	if err := newAPIClient().do(http.MethodPost, path, body, &results); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		fmt.Printf("%s/%s %s\n", result.Kind, result.Name, result.Message)
+	}
+	return nil
+}
+
+func init() {
+	appCmd.AddCommand(appRestartCmd, appScaleCmd, appRollbackCmd)
+
+	for _, cmd := range []*cobra.Command{appRestartCmd, appScaleCmd, appRollbackCmd} {
+		cmd.Flags().StringVarP(&rolloutWorkload, "workload", "w", "", "Only act on the Deployment or StatefulSet with this name")
+	}
+	appScaleCmd.Flags().Int32VarP(&rolloutReplicas, "replicas", "r", 0, "Desired number of replicas")
+	appScaleCmd.MarkFlagRequired("replicas")
+	appRollbackCmd.Flags().Int64Var(&rollbackTo, "to-revision", 0, "Revision to roll back to (default: the previous revision)")
+	appRollbackCmd.Flags().Int64Var(&rollbackTo, "to", 0, "Alias of --to-revision")
+	appRollbackCmd.Flags().BoolVar(&rollbackAllWorkloads, "all-workloads", false, "Roll back all Kubernetes workloads of the application instead of the application")
+	appRollbackCmd.Flags().StringVarP(&rollbackMessage, "message", "m", "", "Message recorded with the new revision")
+}
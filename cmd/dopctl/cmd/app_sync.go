@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchSelector    string
+	batchNamespace   string
+	batchDryRun      bool
+	batchConcurrency int
+)
+
+// batchRequest is the body of POST /applications:batch
+type batchRequest struct {
+	Action      string   `json:"action"`
+	Names       []string `json:"names,omitempty"`
+	Selector    string   `json:"selector,omitempty"`
+	Namespace   string   `json:"namespace,omitempty"`
+	DryRun      bool     `json:"dryRun,omitempty"`
+	Concurrency int      `json:"concurrency,omitempty"`
+}
+
+// batchResponse holds the outcome of a batch per application
+type batchResponse struct {
+	Results []struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		Status    string `json:"status"`
+		Message   string `json:"message"`
+	} `json:"results"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+}
+
+// appSyncCmd represents the app sync command
+var appSyncCmd = &cobra.Command{
+	Use:   "sync [name...]",
+	Short: "Sync applications now",
+	Long: `Sync the applications given by name, or all applications matching a label
+selector and/or namespace, whatever their sync policy, e.g.
+
+  dopctl app sync backend database
+  dopctl app sync --selector team=payments
+  dopctl app sync --namespace shop --dry-run`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBatch("sync", args)
+	},
+}
+
+// runBatch performs action on the applications given by name or selected
+// by the batch flags, and prints the result per application
+func runBatch(action string, names []string) error {
+	if len(names) > 0 && (batchSelector != "" || batchNamespace != "") {
+		return errors.New("application names cannot be combined with --selector or --namespace")
+	}
+	if len(names) == 0 && batchSelector == "" && batchNamespace == "" {
+		return errors.New("application names, --selector or --namespace is required")
+	}
+
+	// Syncs wait for each dependency and sync wave to become healthy, which
+	// may take longer than the default timeout
+	client := newAPIClient()
+	client.httpClient.Timeout = 0
+
+	var resp batchResponse
+	if err := client.do(http.MethodPost, "/applications:batch", batchRequest{
+		Action:      action,
+		Names:       names,
+		Selector:    batchSelector,
+		Namespace:   batchNamespace,
+		DryRun:      batchDryRun,
+		Concurrency: batchConcurrency,
+	}, &resp); err != nil {
+		return err
+	}
+
+	if len(resp.Results) == 0 {
+		fmt.Println("No applications selected")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tNAMESPACE\tRESULT\tMESSAGE")
+	for _, result := range resp.Results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Name, result.Namespace, result.Status, result.Message)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if resp.Failed > 0 {
+		return fmt.Errorf("%d of %d applications failed to %s", resp.Failed, len(resp.Results), action)
+	}
+	return nil
+}
+
+func init() {
+	appCmd.AddCommand(appSyncCmd)
+
+	appSyncCmd.Flags().StringVarP(&batchSelector, "selector", "l", "", "Label selector of the applications, e.g. team=payments")
+	appSyncCmd.Flags().StringVarP(&batchNamespace, "namespace", "n", "", "Only applications in this namespace")
+	appSyncCmd.Flags().BoolVar(&batchDryRun, "dry-run", false, "Only show the applications that would be synced")
+	appSyncCmd.Flags().IntVar(&batchConcurrency, "concurrency", 0, "How many applications to sync at once (default: the server's)")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// resourceNode is the resource tree representation returned by the server
+type resourceNode struct {
+	Kind          string          `json:"kind"`
+	Name          string          `json:"name"`
+	Health        string          `json:"health"`
+	HealthMessage string          `json:"healthMessage"`
+	SyncStatus    string          `json:"syncStatus"`
+	Children      []*resourceNode `json:"children"`
+}
+
+// appTreeCmd represents the app tree command
+var appTreeCmd = &cobra.Command{
+	Use:   "tree <name>",
+	Short: "Print the Kubernetes objects that make up an application",
+	Long: `Print the tree of Kubernetes objects that make up an application,
+following ownerReferences from Deployments to ReplicaSets to Pods.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var roots []*resourceNode
+		if err := newAPIClient().do(http.MethodGet, "/applications/"+url.PathEscape(args[0])+"/resources", nil, &roots); err != nil {
+			return err
+		}
+
+		fmt.Println(args[0])
+		printResourceNodes(roots, "")
+		return nil
+	},
+}
+
+// printResourceNodes prints one level of the resource tree
+func printResourceNodes(nodes []*resourceNode, indent string) {
+	for i, node := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		details := []string{node.Health}
+		if node.SyncStatus != "" {
+			details = append(details, node.SyncStatus)
+		}
+		if node.HealthMessage != "" {
+			details = append(details, node.HealthMessage)
+		}
+		fmt.Printf("%s%s%s/%s (%s)\n", indent, branch, node.Kind, node.Name, strings.Join(details, ", "))
+
+		printResourceNodes(node.Children, indent+next)
+	}
+}
+
+func init() {
+	appCmd.AddCommand(appTreeCmd)
+}
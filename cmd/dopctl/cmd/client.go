@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// apiClient is a minimal client for the DevOps Bridge REST API
+type apiClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	// traceID groups all requests of a command into one trace
+	traceID string
+	// err is returned by every request if the client could not be configured
+	err error
+}
+
+// newAPIClient creates an API client from the connection settings of the
+// current context, flags and environment
+func newAPIClient() *apiClient {
+	tlsConfig, err := clientTLSConfig()
+	return &apiClient{
+		baseURL: strings.TrimSuffix(connectionSetting("server"), "/") + "/api",
+		token:   connectionSetting("token"),
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+		},
+		traceID: traceID(),
+		err:     err,
+	}
+}
+
+// clientTLSConfig builds the TLS configuration from the ca-file,
+// insecure-skip-tls-verify, client-cert and client-key settings
+func clientTLSConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: connectionBool("insecure-skip-tls-verify"),
+	}
+
+	if caFile := connectionSetting("ca-file"); caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("CA file %s contains no certificates", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	certFile, keyFile := connectionSetting("client-cert"), connectionSetting("client-key")
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// traceID returns the trace ID of the TRACEPARENT environment variable, so
+// dopctl can join a trace started by a script, or a new random trace ID
+func traceID() string {
+	// traceparent is version-traceid-parentid-flags, see
+	// https://www.w3.org/TR/trace-context/#traceparent-header
+	parts := strings.Split(os.Getenv("TRACEPARENT"), "-")
+	if len(parts) == 4 && len(parts[1]) == 32 {
+		return parts[1]
+	}
+	return randomHex(16)
+}
+
+// randomHex returns n random bytes, hex-encoded
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out, if out is not nil
+func (c *apiClient) do(method, path string, body, out interface{}) error {
+	resp, err := c.send(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send sends a request with an optional JSON body and returns the response
+// if the server reported success. The caller must close the response body.
+func (c *apiClient) send(method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.sendRequest(req)
+}
+
+// sendRequest authenticates and sends a prepared request and returns the
+// response if the server reported success. The caller must close the
+// response body.
+func (c *apiClient) sendRequest(req *http.Request) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	// Propagate the trace with a new sampled span per request
+	req.Header.Set("traceparent", "00-"+c.traceID+"-"+randomHex(8)+"-01")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach server: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("%w (trace ID %s)", responseError(resp), c.traceID)
+	}
+	return resp, nil
+}
+
+// responseError converts an error response from the server into an error
+func responseError(resp *http.Response) error {
+	var payload struct {
+		Error string `json:"error"`
+		// Fields lists the invalid fields of a rejected application
+		Fields []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"fields"`
+	}
+	data, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(data, &payload); err == nil && payload.Error != "" {
+		msg := payload.Error
+		for _, field := range payload.Fields {
+			msg += "\n  " + field.Field + ": " + field.Message
+		}
+		return fmt.Errorf("server returned %s: %s", resp.Status, msg)
+	}
+	return fmt.Errorf("server returned %s", resp.Status)
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// connectionKeys are the settings that can be stored per context
+var connectionKeys = []string{"server", "token", "ca-file", "insecure-skip-tls-verify", "client-cert", "client-key"}
+
+// connectionSetting returns a connection setting from a flag or environment
+// variable if given, then from the current context, then from the top level
+// of the config file or the flag default
+func connectionSetting(key string) string {
+	if rootCmd.PersistentFlags().Changed(key) {
+		return viper.GetString(key)
+	}
+	if _, ok := os.LookupEnv(envName(key)); ok {
+		return viper.GetString(key)
+	}
+	if name := currentContext(); name != "" {
+		if value := viper.GetString("contexts." + name + "." + key); value != "" {
+			return value
+		}
+	}
+	return viper.GetString(key)
+}
+
+// connectionBool returns a boolean connection setting
+func connectionBool(key string) bool {
+	value, _ := strconv.ParseBool(connectionSetting(key))
+	return value
+}
+
+// currentContext returns the name of the selected context, if any
+func currentContext() string {
+	if name := viper.GetString("context"); name != "" {
+		return name
+	}
+	return viper.GetString("current-context")
+}
+
+// envName returns the environment variable of a setting, e.g. DOPCTL_CA_FILE
+func envName(key string) string {
+	return "DOPCTL_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// configFilePath returns the config file in use, or the default one
+func configFilePath() (string, error) {
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".dopctl.yaml"), nil
+}
+
+// updateConfigFile applies update to the contents of the config file and
+// writes it back, keeping settings it doesn't know about
+func updateConfigFile(update func(config map[string]interface{}) error) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	config := map[string]interface{}{}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if err := update(config); err != nil {
+		return err
+	}
+
+	data, err = yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	// The file may contain tokens
+	return os.WriteFile(path, data, 0o600)
+}
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage connection contexts",
+	Long: `Manage the contexts stored in the config file. A context holds the
+server URL, token and TLS settings of one DevOps Bridge server, and is
+selected with 'dopctl config use-context' or the --context flag.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("Use one of the config subcommands. Run 'dopctl config --help' for usage.")
+	},
+}
+
+// configGetContextsCmd represents the config get-contexts command
+var configGetContextsCmd = &cobra.Command{
+	Use:   "get-contexts",
+	Short: "List contexts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contexts := viper.GetStringMap("contexts")
+		names := make([]string, 0, len(contexts))
+		for name := range contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		current := currentContext()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CURRENT\tNAME\tSERVER")
+		for _, name := range names {
+			marker := ""
+			if name == current {
+				marker = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", marker, name, viper.GetString("contexts."+name+".server"))
+		}
+		return w.Flush()
+	},
+}
+
+// configUseContextCmd represents the config use-context command
+var configUseContextCmd = &cobra.Command{
+	Use:   "use-context NAME",
+	Short: "Select the context used by default",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if !viper.IsSet("contexts." + name) {
+			return fmt.Errorf("context %q not found", name)
+		}
+		if err := updateConfigFile(func(config map[string]interface{}) error {
+			config["current-context"] = name
+			return nil
+		}); err != nil {
+			return err
+		}
+		fmt.Printf("Switched to context %s\n", name)
+		return nil
+	},
+}
+
+// configSetContextCmd represents the config set-context command
+var configSetContextCmd = &cobra.Command{
+	Use:   "set-context NAME",
+	Short: "Create or update a context",
+	Long: `Create or update a context with the connection flags given, e.g.
+
+  dopctl config set-context prod --server https://bridge.example.com \
+    --token $TOKEN --ca-file ca.pem`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := updateConfigFile(func(config map[string]interface{}) error {
+			contexts, _ := config["contexts"].(map[string]interface{})
+			if contexts == nil {
+				contexts = map[string]interface{}{}
+			}
+			context, _ := contexts[name].(map[string]interface{})
+			if context == nil {
+				context = map[string]interface{}{}
+			}
+
+			for _, key := range connectionKeys {
+				flag := rootCmd.PersistentFlags().Lookup(key)
+				if !flag.Changed {
+					continue
+				}
+				if key == "insecure-skip-tls-verify" {
+					context[key] = connectionBool(key)
+				} else {
+					context[key] = flag.Value.String()
+				}
+			}
+
+			contexts[name] = context
+			config["contexts"] = contexts
+			if _, ok := config["current-context"]; !ok {
+				config["current-context"] = name
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		fmt.Printf("Context %s saved\n", name)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetContextsCmd)
+	configCmd.AddCommand(configUseContextCmd)
+	configCmd.AddCommand(configSetContextCmd)
+}
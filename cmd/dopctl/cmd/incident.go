@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	incidentApplication string
+	incidentState       string
+	incidentNote        string
+)
+
+// incident is the incident representation returned by the server
+type incident struct {
+	ID             string     `json:"id"`
+	Application    string     `json:"application"`
+	Namespace      string     `json:"namespace"`
+	State          string     `json:"state"`
+	Summary        string     `json:"summary"`
+	StartedAt      time.Time  `json:"startedAt"`
+	AcknowledgedBy string     `json:"acknowledgedBy"`
+	ResolvedAt     *time.Time `json:"resolvedAt"`
+	ResolvedBy     string     `json:"resolvedBy"`
+}
+
+// incidentCmd represents the incident command
+var incidentCmd = &cobra.Command{
+	Use:   "incident",
+	Short: "Manage application incidents",
+	Long: `Manage incidents opened when an application becomes degraded.
+Incidents are opened automatically when an application goes from Healthy
+to Degraded and resolved automatically when it recovers.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("Use one of the incident subcommands. Run 'dopctl incident --help' for usage.")
+	},
+}
+
+// incidentListCmd represents the incident list command
+var incidentListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List incidents",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := url.Values{}
+		if incidentApplication != "" {
+			query.Set("application", incidentApplication)
+		}
+		if incidentState != "" {
+			query.Set("state", incidentState)
+		}
+
+		var incidents []incident
+		if err := newAPIClient().do(http.MethodGet, "/incidents?"+query.Encode(), nil, &incidents); err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tAPPLICATION\tSTATE\tSTARTED\tDURATION\tOWNER")
+		for _, inc := range incidents {
+			end := time.Now()
+			if inc.ResolvedAt != nil {
+				end = *inc.ResolvedAt
+			}
+			fmt.Fprintf(w, "%s\t%s/%s\t%s\t%s\t%s\t%s\n",
+				inc.ID, inc.Namespace, inc.Application, inc.State,
+				inc.StartedAt.Local().Format(time.DateTime),
+				end.Sub(inc.StartedAt).Round(time.Second), inc.AcknowledgedBy)
+		}
+		return w.Flush()
+	},
+}
+
+// incidentAckCmd represents the incident ack command
+var incidentAckCmd = &cobra.Command{
+	Use:   "ack <id>",
+	Short: "Acknowledge an incident",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runIncidentAction(args[0], "ack", "acknowledged")
+	},
+}
+
+// incidentResolveCmd represents the incident resolve command
+var incidentResolveCmd = &cobra.Command{
+	Use:   "resolve <id>",
+	Short: "Resolve an incident",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runIncidentAction(args[0], "resolve", "resolved")
+	},
+}
+
+// runIncidentAction posts an incident action with the optional note
+func runIncidentAction(id, action, done string) error {
+	body := map[string]string{"note": incidentNote}
+
+	var inc incident
+	if err := newAPIClient().do(http.MethodPost, "/incidents/"+url.PathEscape(id)+"/"+action, body, &inc); err != nil {
+		return err
+	}
+
+	fmt.Printf("Incident %s %s (state: %s)\n", inc.ID, done, inc.State)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(incidentCmd)
+	incidentCmd.AddCommand(incidentListCmd, incidentAckCmd, incidentResolveCmd)
+
+	incidentListCmd.Flags().StringVarP(&incidentApplication, "application", "a", "", "Only list incidents of this application")
+	incidentListCmd.Flags().StringVarP(&incidentState, "state", "s", "", "Only list incidents in this state (Open, Acknowledged, Resolved)")
+	incidentAckCmd.Flags().StringVarP(&incidentNote, "note", "n", "", "Note to attach to the incident")
+	incidentResolveCmd.Flags().StringVarP(&incidentNote, "note", "n", "", "Note to attach to the incident")
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -20,6 +21,9 @@ var rootCmd = &cobra.Command{
 	Short: "DevOps Bridge CLI",
 	Long: `DevOps Bridge CLI is a command-line interface for managing DevOps Bridge.
 It provides commands for managing the server, UI, and other components.`,
+	// Errors are printed by Execute, so don't print them twice
+	SilenceErrors: true,
+	SilenceUsage:  true,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -41,6 +45,17 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.dopctl.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "context of the config file to use (default is current-context)")
+	rootCmd.PersistentFlags().String("server", "http://localhost:8080", "DevOps Bridge server URL")
+	rootCmd.PersistentFlags().String("token", "", "bearer token used to authenticate with the server")
+	rootCmd.PersistentFlags().String("ca-file", "", "CA certificate file used to verify the server")
+	rootCmd.PersistentFlags().Bool("insecure-skip-tls-verify", false, "don't verify the server certificate (insecure)")
+	rootCmd.PersistentFlags().String("client-cert", "", "client certificate file for mutual TLS")
+	rootCmd.PersistentFlags().String("client-key", "", "client private key file for mutual TLS")
+	viper.BindPFlag("context", rootCmd.PersistentFlags().Lookup("context"))
+	for _, key := range connectionKeys {
+		viper.BindPFlag(key, rootCmd.PersistentFlags().Lookup(key))
+	}
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -64,7 +79,9 @@ func initConfig() {
 		viper.SetConfigName(".dopctl")
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	viper.SetEnvPrefix("dopctl")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv() // read in environment variables that match, e.g. DOPCTL_TOKEN or DOPCTL_CA_FILE
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
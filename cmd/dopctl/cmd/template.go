@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// catalogTemplate is the template representation returned by the server
+type catalogTemplate struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Parameters  []templateParameter `json:"parameters"`
+}
+
+// templateParameter describes a parameter of a catalog template
+type templateParameter struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Default     string   `json:"default"`
+	Enum        []string `json:"enum"`
+	Pattern     string   `json:"pattern"`
+}
+
+// templateCmd represents the template command
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Browse the application template catalog",
+	Long: `Browse the catalog of templates applications can be created from with
+'dopctl app create <name> --template <template> --set key=value'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("Use one of the template subcommands. Run 'dopctl template --help' for usage.")
+	},
+}
+
+// templateListCmd represents the template list command
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List templates",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var templates []catalogTemplate
+		if err := newAPIClient().do(http.MethodGet, "/templates", nil, &templates); err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tPARAMETERS\tDESCRIPTION")
+		for _, t := range templates {
+			names := make([]string, len(t.Parameters))
+			for i, param := range t.Parameters {
+				names[i] = param.Name
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", t.Name, strings.Join(names, ","), t.Description)
+		}
+		return w.Flush()
+	},
+}
+
+// templateShowCmd represents the template show command
+var templateShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show the parameters of a template",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var t catalogTemplate
+		if err := newAPIClient().do(http.MethodGet, "/templates/"+url.PathEscape(args[0]), nil, &t); err != nil {
+			return err
+		}
+
+		fmt.Printf("Template %s: %s\n\n", t.Name, t.Description)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PARAMETER\tTYPE\tREQUIRED\tDEFAULT\tALLOWED\tDESCRIPTION")
+		for _, param := range t.Parameters {
+			paramType := param.Type
+			if paramType == "" {
+				paramType = "string"
+			}
+			allowed := strings.Join(param.Enum, ",")
+			if param.Pattern != "" {
+				allowed = param.Pattern
+			}
+			fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\n",
+				param.Name, paramType, param.Required, param.Default, allowed, param.Description)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateListCmd, templateShowCmd)
+}
@@ -2,8 +2,13 @@ package api
 
 import (
 	"context"
+	"errors"
 
+	"github.com/sysintelligent/devops-bridge/server/auth"
+	"github.com/sysintelligent/devops-bridge/server/autosync"
+	"github.com/sysintelligent/devops-bridge/server/batch"
 	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"github.com/sysintelligent/devops-bridge/server/revisions"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -11,18 +16,35 @@ import (
 )
 
 // RegisterGRPCServices registers all gRPC services with the server
-func RegisterGRPCServices(server *grpc.Server, k8sClient *kubernetes.Client) {
+func RegisterGRPCServices(server *grpc.Server, k8sClient *kubernetes.Client, authService *auth.Service, opts ...Option) {
+	o := newOptions(opts)
+
 	// Register the application service
 	RegisterApplicationServiceServer(server, &applicationServiceServer{
-		k8sClient: k8sClient,
+		k8sClient:   k8sClient,
+		authService: authService,
+		revisions:   o.revisions,
+		scheduler:   o.scheduler,
+		batch:       o.batch,
 	})
+
+	// Register services of optional subsystems
+	if o.incidents != nil {
+		RegisterIncidentServiceServer(server, &incidentServiceServer{
+			tracker: o.incidents,
+		})
+	}
 }
 
 // applicationServiceServer implements the ApplicationService gRPC service
 type applicationServiceServer struct {
 	// This would normally be generated by protoc
 	UnimplementedApplicationServiceServer
-	k8sClient *kubernetes.Client
+	k8sClient   *kubernetes.Client
+	authService *auth.Service
+	revisions   *revisions.Store
+	scheduler   *autosync.Scheduler
+	batch       *batch.Runner
 }
 
 // UnimplementedApplicationServiceServer is a placeholder for the generated code
@@ -41,10 +63,40 @@ type ApplicationServiceServer interface {
 	GetApplication(context.Context, *ApplicationRequest) (*Application, error)
 	// CreateApplication creates a new application
 	CreateApplication(context.Context, *Application) (*Application, error)
-	// UpdateApplication updates an existing application
-	UpdateApplication(context.Context, *Application) (*Application, error)
+	// UpdateApplication updates an existing application, or only the fields in its update mask
+	UpdateApplication(context.Context, *UpdateApplicationRequest) (*Application, error)
 	// DeleteApplication deletes an application
 	DeleteApplication(context.Context, *ApplicationRequest) (*emptypb.Empty, error)
+	// GetApplicationEvents returns the Kubernetes events of an application
+	GetApplicationEvents(context.Context, *ApplicationEventsRequest) (*EventList, error)
+	// GetApplicationResources returns the resource tree of an application
+	GetApplicationResources(context.Context, *ApplicationRequest) (*ResourceTree, error)
+	// GetApplicationManifests renders the desired objects of an application from its source
+	GetApplicationManifests(context.Context, *ApplicationRequest) (*ManifestList, error)
+	// RestartApplication restarts the workloads of an application
+	RestartApplication(context.Context, *ApplicationActionRequest) (*ApplicationActionResponse, error)
+	// ScaleApplication scales the workloads of an application
+	ScaleApplication(context.Context, *ApplicationActionRequest) (*ApplicationActionResponse, error)
+	// PauseApplication pauses the rollout of an application
+	PauseApplication(context.Context, *ApplicationActionRequest) (*ApplicationActionResponse, error)
+	// ResumeApplication resumes the rollout of an application
+	ResumeApplication(context.Context, *ApplicationActionRequest) (*ApplicationActionResponse, error)
+	// RollbackApplication rolls the workloads of an application back to a previous revision
+	RollbackApplication(context.Context, *ApplicationActionRequest) (*ApplicationActionResponse, error)
+	// ListApplicationRevisions returns the revisions of an application, newest first
+	ListApplicationRevisions(context.Context, *ApplicationRequest) (*RevisionList, error)
+	// GetApplicationRevision returns a single revision of an application
+	GetApplicationRevision(context.Context, *RevisionRequest) (*Revision, error)
+	// DiffApplicationRevisions compares two revisions of an application
+	DiffApplicationRevisions(context.Context, *RevisionDiffRequest) (*RevisionDiff, error)
+	// RollbackApplicationToRevision restores the spec of a previous revision
+	RollbackApplicationToRevision(context.Context, *RevisionRequest) (*Application, error)
+	// RefreshApplication refreshes the sync status of an application now, and syncs it if asked
+	RefreshApplication(context.Context, *RefreshApplicationRequest) (*Application, error)
+	// GetDependencyGraph returns how applications depend on each other
+	GetDependencyGraph(context.Context, *emptypb.Empty) (*DependencyGraph, error)
+	// BatchApplications performs an action on many applications at once
+	BatchApplications(context.Context, *BatchRequest) (*BatchResponse, error)
 }
 
 // ApplicationList is a list of applications
@@ -65,16 +117,79 @@ type Application struct {
 	Name string
 	// Namespace is the Kubernetes namespace
 	Namespace string
+	// Labels organize applications, e.g. by team
+	Labels map[string]string
 	// Status is the current status of the application
 	Status string
 	// SyncStatus is the current sync status of the application
 	SyncStatus string
+	// AutoSync syncs the application when its desired state changes
+	AutoSync bool
+	// SelfHeal also syncs the application when its live state drifted
+	SelfHeal bool
+	// Prune deletes objects that are no longer desired when syncing
+	Prune bool
+	// DependsOn names the applications that must be healthy before this one is synced
+	DependsOn []string
+	// Source is where the desired state of the application comes from
+	Source *ApplicationSource
+	// TargetCommit is the commit the source revision resolved to at the last refresh
+	TargetCommit string
+	// SyncedCommit is the commit of the source the application was last synced to
+	SyncedCommit string
+	// ResourceVersion changes whenever the application is created or updated
+	ResourceVersion string
+}
+
+// ApplicationSource is where the manifests of an application are in a Git
+// repository
+type ApplicationSource struct {
+	// RepoURL is the URL of the Git repository
+	RepoURL string
+	// Revision is the branch, tag or commit to deploy
+	Revision string
+	// Path is a directory of manifests or a Helm chart within the repository
+	Path string
+	// Helm configures the rendering of a chart
+	Helm *HelmSource
+	// Kustomize overrides settings of a Kustomize overlay
+	Kustomize *KustomizeSource
+	// Template renders the manifests from a catalog template instead
+	Template *TemplateSource
+}
+
+// HelmSource configures how a Helm chart is rendered
+type HelmSource struct {
+	// ReleaseName is the name of the release
+	ReleaseName string
+	// ValueFiles are values files relative to the chart
+	ValueFiles []string
+	// Values is a YAML document of values merged over the values files
+	Values string
+}
+
+// KustomizeSource overrides settings of a Kustomize overlay
+type KustomizeSource struct {
+	// Images replace image names, tags or digests, e.g. name=newName:tag
+	Images []string
+	// NamePrefix is prepended to the names of all objects
+	NamePrefix string
+	// CommonLabels are added to all objects and their selectors
+	CommonLabels map[string]string
+}
+
+// TemplateSource selects a catalog template and its parameters
+type TemplateSource struct {
+	// Name is the name of the template
+	Name string
+	// Parameters are the values of the template parameters
+	Parameters map[string]string
 }
 
 // GetApplications returns a list of all applications
 func (s *applicationServiceServer) GetApplications(ctx context.Context, req *emptypb.Empty) (*ApplicationList, error) {
 	// Get applications from Kubernetes
-	apps, err := s.k8sClient.GetApplications()
+	apps, err := s.k8sClient.GetApplications(ctx)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Failed to get applications: %v", err)
 	}
@@ -82,12 +197,7 @@ func (s *applicationServiceServer) GetApplications(ctx context.Context, req *emp
 	// Convert to gRPC response
 	var result ApplicationList
 	for _, app := range apps {
-		result.Applications = append(result.Applications, &Application{
-			Name:       app.Name,
-			Namespace:  app.Namespace,
-			Status:     string(app.Status),
-			SyncStatus: string(app.SyncStatus),
-		})
+		result.Applications = append(result.Applications, toGRPCApplication(app))
 	}
 
 	return &result, nil
@@ -96,62 +206,216 @@ func (s *applicationServiceServer) GetApplications(ctx context.Context, req *emp
 // GetApplication returns a single application by name
 func (s *applicationServiceServer) GetApplication(ctx context.Context, req *ApplicationRequest) (*Application, error) {
 	// Get application from Kubernetes
-	app, err := s.k8sClient.GetApplication(req.Name)
+	app, err := s.k8sClient.GetApplication(ctx, req.Name)
 	if err != nil {
 		return nil, status.Errorf(codes.NotFound, "Application not found: %v", err)
 	}
 
 	// Convert to gRPC response
-	return &Application{
-		Name:       app.Name,
-		Namespace:  app.Namespace,
-		Status:     string(app.Status),
-		SyncStatus: string(app.SyncStatus),
-	}, nil
+	return toGRPCApplication(app), nil
 }
 
 // CreateApplication creates a new application
 func (s *applicationServiceServer) CreateApplication(ctx context.Context, req *Application) (*Application, error) {
 	// Convert to Kubernetes application
-	app := &kubernetes.Application{
-		Name:       req.Name,
-		Namespace:  req.Namespace,
-		Status:     kubernetes.ApplicationStatus(req.Status),
-		SyncStatus: kubernetes.SyncStatus(req.SyncStatus),
-	}
+	app := fromGRPCApplication(req)
 
 	// Create application in Kubernetes
-	if err := s.k8sClient.CreateApplication(app); err != nil {
+	if err := s.k8sClient.CreateApplication(ctx, app); err != nil {
+		var validationErr *kubernetes.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, validationStatus(validationErr)
+		}
+		if errors.Is(err, kubernetes.ErrInvalidSource) {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid source: %v", err)
+		}
+		if errors.Is(err, kubernetes.ErrApplicationExists) {
+			return nil, status.Errorf(codes.AlreadyExists, "Application already exists: %v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "Failed to create application: %v", err)
 	}
 
-	return req, nil
+	// Record the new application
+	s.recordRevision(ctx, *app, revisions.ReasonCreate)
+
+	return toGRPCApplication(app), nil
 }
 
-// UpdateApplication updates an existing application
-func (s *applicationServiceServer) UpdateApplication(ctx context.Context, req *Application) (*Application, error) {
+// UpdateApplication updates an existing application. With an update mask,
+// only the fields it lists are changed; otherwise the application is
+// replaced.
+func (s *applicationServiceServer) UpdateApplication(ctx context.Context, req *UpdateApplicationRequest) (*Application, error) {
+	if req.Application == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Application is required")
+	}
+	name := req.Application.Name
+
 	// Convert to Kubernetes application
-	app := &kubernetes.Application{
-		Name:       req.Name,
-		Namespace:  req.Namespace,
-		Status:     kubernetes.ApplicationStatus(req.Status),
-		SyncStatus: kubernetes.SyncStatus(req.SyncStatus),
+	app := fromGRPCApplication(req.Application)
+
+	// A resource version in the request must be current, as must the one
+	// in the if-match metadata, if any
+	version, err := s.checkIfMatch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	conflict := codes.Aborted
+	if app.ResourceVersion == "" && version != "" {
+		app.ResourceVersion = version
+		conflict = codes.FailedPrecondition
+	}
+
+	// Change only the masked fields of the current application, failing
+	// with Aborted if it changes in the meantime
+	if paths := req.UpdateMask.GetPaths(); len(paths) > 0 {
+		existing, err := s.k8sClient.GetApplication(ctx, name)
+		if err != nil {
+			return nil, status.Errorf(codes.NotFound, "Application not found: %v", err)
+		}
+		if app, err = applyUpdateMask(existing, app, paths); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid update mask: %v", err)
+		}
+		if app.ResourceVersion == "" {
+			app.ResourceVersion = existing.ResourceVersion
+		}
 	}
 
 	// Update application in Kubernetes
-	if err := s.k8sClient.UpdateApplication(req.Name, app); err != nil {
+	if err := s.k8sClient.UpdateApplication(ctx, name, app); err != nil {
+		var validationErr *kubernetes.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, validationStatus(validationErr)
+		}
+		if errors.Is(err, kubernetes.ErrInvalidSource) {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid source: %v", err)
+		}
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			return nil, status.Errorf(codes.NotFound, "Application not found: %v", err)
+		}
+		if errors.Is(err, kubernetes.ErrConflict) {
+			return nil, status.Errorf(conflict, "Application has been modified: %v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "Failed to update application: %v", err)
 	}
 
-	return req, nil
+	// Record the change
+	s.recordRevision(ctx, *app, revisions.ReasonUpdate)
+
+	return toGRPCApplication(app), nil
 }
 
 // DeleteApplication deletes an application
 func (s *applicationServiceServer) DeleteApplication(ctx context.Context, req *ApplicationRequest) (*emptypb.Empty, error) {
+	// Delete only the version given in the if-match metadata, if any
+	version, err := s.checkIfMatch(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
 	// Delete application from Kubernetes
-	if err := s.k8sClient.DeleteApplication(req.Name); err != nil {
+	if err := s.k8sClient.DeleteApplication(ctx, req.Name, version); err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			return nil, status.Errorf(codes.NotFound, "Application not found: %v", err)
+		}
+		if errors.Is(err, kubernetes.ErrConflict) {
+			return nil, status.Errorf(codes.FailedPrecondition, "Application has been modified: %v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "Failed to delete application: %v", err)
 	}
 
 	return &emptypb.Empty{}, nil
 }
+
+// toGRPCApplication converts an application to its gRPC representation
+func toGRPCApplication(app *kubernetes.Application) *Application {
+	return &Application{
+		Name:            app.Name,
+		Namespace:       app.Namespace,
+		Labels:          app.Labels,
+		Status:          string(app.Status),
+		SyncStatus:      string(app.SyncStatus),
+		AutoSync:        app.SyncPolicy.AutoSync,
+		SelfHeal:        app.SyncPolicy.SelfHeal,
+		Prune:           app.SyncPolicy.Prune,
+		DependsOn:       app.DependsOn,
+		Source:          toGRPCSource(app.Source),
+		TargetCommit:    app.TargetCommit,
+		SyncedCommit:    app.SyncedCommit,
+		ResourceVersion: app.ResourceVersion,
+	}
+}
+
+// toGRPCSource converts an application source to its gRPC representation
+func toGRPCSource(source *kubernetes.ApplicationSource) *ApplicationSource {
+	if source == nil {
+		return nil
+	}
+	result := &ApplicationSource{
+		RepoURL:  source.RepoURL,
+		Revision: source.Revision,
+		Path:     source.Path,
+	}
+	if helm := source.Helm; helm != nil {
+		result.Helm = &HelmSource{
+			ReleaseName: helm.ReleaseName,
+			ValueFiles:  helm.ValueFiles,
+			Values:      helm.Values,
+		}
+	}
+	if kustomize := source.Kustomize; kustomize != nil {
+		result.Kustomize = &KustomizeSource{
+			Images:       kustomize.Images,
+			NamePrefix:   kustomize.NamePrefix,
+			CommonLabels: kustomize.CommonLabels,
+		}
+	}
+	if template := source.Template; template != nil {
+		result.Template = &TemplateSource{
+			Name:       template.Name,
+			Parameters: template.Parameters,
+		}
+	}
+	return result
+}
+
+// toSource converts the source of a gRPC application
+func toSource(source *ApplicationSource) *kubernetes.ApplicationSource {
+	if source == nil {
+		return nil
+	}
+	result := &kubernetes.ApplicationSource{
+		RepoURL:  source.RepoURL,
+		Revision: source.Revision,
+		Path:     source.Path,
+	}
+	if helm := source.Helm; helm != nil {
+		result.Helm = &kubernetes.HelmSource{
+			ReleaseName: helm.ReleaseName,
+			ValueFiles:  helm.ValueFiles,
+			Values:      helm.Values,
+		}
+	}
+	if kustomize := source.Kustomize; kustomize != nil {
+		result.Kustomize = &kubernetes.KustomizeSource{
+			Images:       kustomize.Images,
+			NamePrefix:   kustomize.NamePrefix,
+			CommonLabels: kustomize.CommonLabels,
+		}
+	}
+	if template := source.Template; template != nil {
+		result.Template = &kubernetes.TemplateSource{
+			Name:       template.Name,
+			Parameters: template.Parameters,
+		}
+	}
+	return result
+}
+
+// toSyncPolicy converts the sync policy of a gRPC application
+func toSyncPolicy(app *Application) kubernetes.SyncPolicy {
+	return kubernetes.SyncPolicy{
+		AutoSync: app.AutoSync,
+		SelfHeal: app.SelfHeal,
+		Prune:    app.Prune,
+	}
+}
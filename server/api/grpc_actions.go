@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ApplicationActionRequest is a request to act on the workloads of an application
+type ApplicationActionRequest struct {
+	// Name is the name of the application
+	Name string
+	// Workload restricts the action to the workload with this name
+	Workload string
+	// Replicas is the desired number of replicas, required for scale
+	Replicas *int32
+	// ToRevision is the revision to roll back to; zero means the previous one
+	ToRevision int64
+}
+
+// ApplicationActionResponse holds the outcome of an action per workload
+type ApplicationActionResponse struct {
+	// Results are the outcomes per workload
+	Results []*WorkloadResult
+}
+
+// WorkloadResult is the outcome of an action on a single workload
+type WorkloadResult struct {
+	// Kind is Deployment or StatefulSet
+	Kind string
+	// Name is the name of the workload
+	Name string
+	// Message describes what was done
+	Message string
+}
+
+// RestartApplication restarts the workloads of an application
+func (s *applicationServiceServer) RestartApplication(ctx context.Context, req *ApplicationActionRequest) (*ApplicationActionResponse, error) {
+	return s.rollout(ctx, kubernetes.RolloutRestart, req)
+}
+
+// ScaleApplication scales the workloads of an application
+func (s *applicationServiceServer) ScaleApplication(ctx context.Context, req *ApplicationActionRequest) (*ApplicationActionResponse, error) {
+	return s.rollout(ctx, kubernetes.RolloutScale, req)
+}
+
+// PauseApplication pauses the rollout of an application
+func (s *applicationServiceServer) PauseApplication(ctx context.Context, req *ApplicationActionRequest) (*ApplicationActionResponse, error) {
+	return s.rollout(ctx, kubernetes.RolloutPause, req)
+}
+
+// ResumeApplication resumes the rollout of an application
+func (s *applicationServiceServer) ResumeApplication(ctx context.Context, req *ApplicationActionRequest) (*ApplicationActionResponse, error) {
+	return s.rollout(ctx, kubernetes.RolloutResume, req)
+}
+
+// RollbackApplication rolls the workloads of an application back to a previous revision
+func (s *applicationServiceServer) RollbackApplication(ctx context.Context, req *ApplicationActionRequest) (*ApplicationActionResponse, error) {
+	return s.rollout(ctx, kubernetes.RolloutRollback, req)
+}
+
+// rollout performs a rollout action and converts the result
+func (s *applicationServiceServer) rollout(ctx context.Context, action kubernetes.RolloutAction, req *ApplicationActionRequest) (*ApplicationActionResponse, error) {
+	results, err := s.k8sClient.RolloutApplication(ctx, req.Name, kubernetes.RolloutRequest{
+		Action:     action,
+		Workload:   req.Workload,
+		Replicas:   req.Replicas,
+		ToRevision: req.ToRevision,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, kubernetes.ErrApplicationNotFound):
+			return nil, status.Errorf(codes.NotFound, "Application not found: %v", err)
+		case errors.Is(err, kubernetes.ErrInvalidRolloutAction):
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid action: %v", err)
+		case errors.Is(err, kubernetes.ErrNoWorkloads):
+			return nil, status.Errorf(codes.NotFound, "No workloads: %v", err)
+		case errors.Is(err, kubernetes.ErrNoRevision):
+			return nil, status.Errorf(codes.FailedPrecondition, "Cannot roll back: %v", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "Failed to %s application: %v", action, err)
+		}
+	}
+
+	// Convert to gRPC response
+	var response ApplicationActionResponse
+	for _, result := range results {
+		response.Results = append(response.Results, &WorkloadResult{
+			Kind:    result.Kind,
+			Name:    result.Name,
+			Message: result.Message,
+		})
+	}
+	return &response, nil
+}
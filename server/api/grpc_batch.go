@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sysintelligent/devops-bridge/server/auth"
+	"github.com/sysintelligent/devops-bridge/server/autosync"
+	"github.com/sysintelligent/devops-bridge/server/batch"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BatchRequest selects applications and the action to perform on them
+type BatchRequest struct {
+	// Action is sync, restart or delete
+	Action string
+	// Names lists the applications explicitly
+	Names []string
+	// Selector is a label selector, e.g. team=payments
+	Selector string
+	// Namespace restricts the selection to applications in this namespace
+	Namespace string
+	// DryRun reports the applications that would be acted on without changing anything
+	DryRun bool
+	// Concurrency is how many applications are acted on at once
+	Concurrency int32
+}
+
+// BatchResponse holds the outcome per application
+type BatchResponse struct {
+	// Results are the outcomes per application, ordered by name
+	Results []*BatchResult
+	// Succeeded, Failed and Skipped count the results by status
+	Succeeded int32
+	Failed    int32
+	Skipped   int32
+}
+
+// BatchResult is the outcome of a batch action on a single application
+type BatchResult struct {
+	// Name is the name of the application
+	Name string
+	// Namespace is the Kubernetes namespace
+	Namespace string
+	// Status is Succeeded, Failed, Skipped or DryRun
+	Status string
+	// Message describes what was done or why it failed
+	Message string
+}
+
+// BatchApplications performs an action on many applications at once
+func (s *applicationServiceServer) BatchApplications(ctx context.Context, req *BatchRequest) (*BatchResponse, error) {
+	if s.batch == nil {
+		return nil, status.Error(codes.Unimplemented, "Batch operations are not enabled")
+	}
+
+	var actor batch.Actor
+	if user, ok := auth.UserFromContext(ctx); ok {
+		actor = batch.Actor{ID: user.ID, Name: user.Name}
+	}
+	resp, err := s.batch.Run(ctx, batch.Request{
+		Action:      batch.Action(req.Action),
+		Names:       req.Names,
+		Selector:    req.Selector,
+		Namespace:   req.Namespace,
+		DryRun:      req.DryRun,
+		Concurrency: int(req.Concurrency),
+	}, actor)
+	if err != nil {
+		if errors.Is(err, batch.ErrInvalidRequest) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		if errors.Is(err, autosync.ErrNotLeader) {
+			return nil, status.Errorf(codes.Unavailable, "Only the leader syncs applications, retry to reach it: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to run batch: %v", err)
+	}
+
+	// Convert to gRPC response
+	result := &BatchResponse{
+		Results:   make([]*BatchResult, len(resp.Results)),
+		Succeeded: int32(resp.Succeeded),
+		Failed:    int32(resp.Failed),
+		Skipped:   int32(resp.Skipped),
+	}
+	for i, r := range resp.Results {
+		result.Results[i] = &BatchResult{
+			Name:      r.Name,
+			Namespace: r.Namespace,
+			Status:    string(r.Status),
+			Message:   r.Message,
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ApplicationEventsRequest is a request for the events of an application
+type ApplicationEventsRequest struct {
+	// Name is the name of the application
+	Name string
+	// Type restricts the events to Normal or Warning events
+	Type string
+}
+
+// EventList is a list of Kubernetes events
+type EventList struct {
+	// Events is the list of events
+	Events []*Event
+}
+
+// Event is a deduplicated Kubernetes event about an object of an application
+type Event struct {
+	// Type is Normal or Warning
+	Type string
+	// Reason is a short machine-readable reason, e.g. BackOff
+	Reason string
+	// Message is a human-readable description
+	Message string
+	// ObjectKind is the kind of the object the event is about
+	ObjectKind string
+	// ObjectName is the name of the object the event is about
+	ObjectName string
+	// Count is how often the event occurred
+	Count int32
+	// FirstSeen is when the event first occurred
+	FirstSeen *timestamppb.Timestamp
+	// LastSeen is when the event last occurred
+	LastSeen *timestamppb.Timestamp
+}
+
+// GetApplicationEvents returns the Kubernetes events of an application
+func (s *applicationServiceServer) GetApplicationEvents(ctx context.Context, req *ApplicationEventsRequest) (*EventList, error) {
+	// Get events from Kubernetes
+	events, err := s.k8sClient.GetApplicationEvents(ctx, req.Name, req.Type)
+	if err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			return nil, status.Errorf(codes.NotFound, "Application not found: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to get events: %v", err)
+	}
+
+	// Convert to gRPC response
+	var result EventList
+	for _, event := range events {
+		result.Events = append(result.Events, &Event{
+			Type:       event.Type,
+			Reason:     event.Reason,
+			Message:    event.Message,
+			ObjectKind: event.ObjectKind,
+			ObjectName: event.ObjectName,
+			Count:      event.Count,
+			FirstSeen:  timestamppb.New(event.FirstSeen),
+			LastSeen:   timestamppb.New(event.LastSeen),
+		})
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// DependencyGraph shows how applications depend on each other
+type DependencyGraph struct {
+	// Nodes are the applications, ordered by wave and name
+	Nodes []*GraphNode
+	// Edges point from an application to one of its dependencies
+	Edges []*GraphEdge
+}
+
+// GraphNode is an application in the dependency graph
+type GraphNode struct {
+	// Name is the name of the application
+	Name string
+	// Namespace is the Kubernetes namespace
+	Namespace string
+	// Status is the current status of the application
+	Status string
+	// SyncStatus is the current sync status of the application
+	SyncStatus string
+	// Wave is 0 without dependencies, else one more than the highest wave of the dependencies
+	Wave int32
+	// DependsOn names the dependencies of the application
+	DependsOn []string
+	// BlockedBy lists the dependencies that are missing or not healthy
+	BlockedBy []string
+}
+
+// GraphEdge is a dependency of one application on another
+type GraphEdge struct {
+	// From is the dependent application
+	From string
+	// To is the dependency
+	To string
+	// Missing is set when the dependency does not exist
+	Missing bool
+}
+
+// GetDependencyGraph returns how applications depend on each other
+func (s *applicationServiceServer) GetDependencyGraph(ctx context.Context, req *emptypb.Empty) (*DependencyGraph, error) {
+	graph, err := s.k8sClient.GetDependencyGraph()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to get dependency graph: %v", err)
+	}
+
+	// Convert to gRPC response
+	result := &DependencyGraph{
+		Nodes: make([]*GraphNode, len(graph.Nodes)),
+		Edges: make([]*GraphEdge, len(graph.Edges)),
+	}
+	for i, node := range graph.Nodes {
+		result.Nodes[i] = &GraphNode{
+			Name:       node.Name,
+			Namespace:  node.Namespace,
+			Status:     string(node.Status),
+			SyncStatus: string(node.SyncStatus),
+			Wave:       int32(node.Wave),
+			DependsOn:  node.DependsOn,
+			BlockedBy:  node.BlockedBy,
+		}
+	}
+	for i, edge := range graph.Edges {
+		result.Edges[i] = &GraphEdge{From: edge.From, To: edge.To, Missing: edge.Missing}
+	}
+	return result, nil
+}
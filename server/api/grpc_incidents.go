@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sysintelligent/devops-bridge/server/auth"
+	"github.com/sysintelligent/devops-bridge/server/incidents"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// incidentServiceServer implements the IncidentService gRPC service
+type incidentServiceServer struct {
+	// This would normally be generated by protoc
+	UnimplementedIncidentServiceServer
+	tracker *incidents.Tracker
+}
+
+// UnimplementedIncidentServiceServer is a placeholder for the generated code
+type UnimplementedIncidentServiceServer struct{}
+
+// RegisterIncidentServiceServer is a placeholder for the generated code
+func RegisterIncidentServiceServer(server *grpc.Server, service IncidentServiceServer) {
+	// This would normally be generated by protoc
+}
+
+// IncidentServiceServer is the server API for IncidentService service.
+type IncidentServiceServer interface {
+	// ListIncidents returns incidents, optionally filtered by application and state
+	ListIncidents(context.Context, *IncidentListRequest) (*IncidentList, error)
+	// GetIncident returns a single incident by ID
+	GetIncident(context.Context, *IncidentRequest) (*Incident, error)
+	// AcknowledgeIncident marks an incident as being handled
+	AcknowledgeIncident(context.Context, *IncidentActionRequest) (*Incident, error)
+	// ResolveIncident manually resolves an incident
+	ResolveIncident(context.Context, *IncidentActionRequest) (*Incident, error)
+	// AddIncidentNote attaches a note to an incident
+	AddIncidentNote(context.Context, *IncidentActionRequest) (*Incident, error)
+}
+
+// IncidentListRequest is a request for a filtered list of incidents
+type IncidentListRequest struct {
+	// Application restricts the list to a single application
+	Application string
+	// State restricts the list to a single state
+	State string
+}
+
+// IncidentList is a list of incidents
+type IncidentList struct {
+	// Incidents is the list of incidents
+	Incidents []*Incident
+}
+
+// IncidentRequest is a request for a specific incident
+type IncidentRequest struct {
+	// ID is the ID of the incident
+	ID string
+}
+
+// IncidentActionRequest is a request to change a specific incident
+type IncidentActionRequest struct {
+	// ID is the ID of the incident
+	ID string
+	// Note is an optional note to attach to the incident
+	Note string
+}
+
+// IncidentNote is a note attached to an incident
+type IncidentNote struct {
+	// Author is the ID of the user who wrote the note
+	Author string
+	// Text is the content of the note
+	Text string
+	// CreatedAt is when the note was written
+	CreatedAt *timestamppb.Timestamp
+}
+
+// Incident is a period during which an application was degraded
+type Incident struct {
+	// ID is the ID of the incident
+	ID string
+	// Application is the name of the affected application
+	Application string
+	// Namespace is the namespace of the affected application
+	Namespace string
+	// State is the lifecycle state of the incident
+	State string
+	// Summary describes the health transition that opened the incident
+	Summary string
+	// StartedAt is when the application became degraded
+	StartedAt *timestamppb.Timestamp
+	// AcknowledgedAt is when the incident was acknowledged, if it was
+	AcknowledgedAt *timestamppb.Timestamp
+	// AcknowledgedBy is the ID of the user who acknowledged the incident
+	AcknowledgedBy string
+	// ResolvedAt is when the incident was resolved, if it was
+	ResolvedAt *timestamppb.Timestamp
+	// ResolvedBy is the ID of the user who resolved the incident
+	ResolvedBy string
+	// Notes are the notes attached to the incident
+	Notes []*IncidentNote
+}
+
+// ListIncidents returns incidents, optionally filtered by application and state
+func (s *incidentServiceServer) ListIncidents(ctx context.Context, req *IncidentListRequest) (*IncidentList, error) {
+	list := s.tracker.List(incidents.Filter{
+		Application: req.Application,
+		State:       incidents.State(req.State),
+	})
+
+	// Convert to gRPC response
+	var result IncidentList
+	for i := range list {
+		result.Incidents = append(result.Incidents, toGRPCIncident(list[i]))
+	}
+
+	return &result, nil
+}
+
+// GetIncident returns a single incident by ID
+func (s *incidentServiceServer) GetIncident(ctx context.Context, req *IncidentRequest) (*Incident, error) {
+	incident, err := s.tracker.Get(req.ID)
+	if err != nil {
+		return nil, incidentStatusError(err)
+	}
+
+	return toGRPCIncident(incident), nil
+}
+
+// AcknowledgeIncident marks an incident as being handled
+func (s *incidentServiceServer) AcknowledgeIncident(ctx context.Context, req *IncidentActionRequest) (*Incident, error) {
+	return s.applyAction(ctx, req, s.tracker.Acknowledge)
+}
+
+// ResolveIncident manually resolves an incident
+func (s *incidentServiceServer) ResolveIncident(ctx context.Context, req *IncidentActionRequest) (*Incident, error) {
+	return s.applyAction(ctx, req, s.tracker.Resolve)
+}
+
+// AddIncidentNote attaches a note to an incident
+func (s *incidentServiceServer) AddIncidentNote(ctx context.Context, req *IncidentActionRequest) (*Incident, error) {
+	if req.Note == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Note must not be empty")
+	}
+	return s.applyAction(ctx, req, s.tracker.AddNote)
+}
+
+// applyAction applies action to the requested incident on behalf of the
+// authenticated user
+func (s *incidentServiceServer) applyAction(ctx context.Context, req *IncidentActionRequest, action func(id, actor, note string) (incidents.Incident, error)) (*Incident, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	incident, err := action(req.ID, user.ID, req.Note)
+	if err != nil {
+		return nil, incidentStatusError(err)
+	}
+
+	return toGRPCIncident(incident), nil
+}
+
+// incidentStatusError maps incident errors to gRPC status errors
+func incidentStatusError(err error) error {
+	switch {
+	case errors.Is(err, incidents.ErrIncidentNotFound):
+		return status.Errorf(codes.NotFound, "Incident not found: %v", err)
+	case errors.Is(err, incidents.ErrIncidentResolved):
+		return status.Errorf(codes.FailedPrecondition, "Incident is already resolved: %v", err)
+	default:
+		return status.Errorf(codes.Internal, "Failed to update incident: %v", err)
+	}
+}
+
+// toGRPCIncident converts an incident to its gRPC representation
+func toGRPCIncident(incident incidents.Incident) *Incident {
+	result := &Incident{
+		ID:             incident.ID,
+		Application:    incident.Application,
+		Namespace:      incident.Namespace,
+		State:          string(incident.State),
+		Summary:        incident.Summary,
+		StartedAt:      timestamppb.New(incident.StartedAt),
+		AcknowledgedBy: incident.AcknowledgedBy,
+		ResolvedBy:     incident.ResolvedBy,
+	}
+	if incident.AcknowledgedAt != nil {
+		result.AcknowledgedAt = timestamppb.New(*incident.AcknowledgedAt)
+	}
+	if incident.ResolvedAt != nil {
+		result.ResolvedAt = timestamppb.New(*incident.ResolvedAt)
+	}
+	for _, note := range incident.Notes {
+		result.Notes = append(result.Notes, &IncidentNote{
+			Author:    note.Author,
+			Text:      note.Text,
+			CreatedAt: timestamppb.New(note.CreatedAt),
+		})
+	}
+	return result
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ManifestList is the rendered desired state of an application
+type ManifestList struct {
+	// Commit is the commit of the source the manifests were rendered from
+	Commit string
+	// Manifests are the desired objects, each encoded as JSON
+	Manifests []string
+}
+
+// GetApplicationManifests renders the desired objects of an application
+// from its source
+func (s *applicationServiceServer) GetApplicationManifests(ctx context.Context, req *ApplicationRequest) (*ManifestList, error) {
+	manifests, err := s.k8sClient.GetApplicationManifests(ctx, req.Name)
+	if err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			return nil, status.Errorf(codes.NotFound, "Application not found: %v", err)
+		}
+		if errors.Is(err, kubernetes.ErrNoSource) {
+			return nil, status.Errorf(codes.FailedPrecondition, "Application has no source: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to render application manifests: %v", err)
+	}
+
+	// Convert to gRPC response
+	result := &ManifestList{Commit: manifests.Commit}
+	for _, obj := range manifests.Objects {
+		data, err := obj.MarshalJSON()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Failed to encode manifest: %v", err)
+		}
+		result.Manifests = append(result.Manifests, string(data))
+	}
+	return result, nil
+}
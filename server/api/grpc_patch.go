@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// UpdateApplicationRequest updates an existing application
+type UpdateApplicationRequest struct {
+	// Application holds the new values. Its Name selects the application.
+	Application *Application
+	// UpdateMask lists the fields to change, e.g. auto_sync or
+	// source.revision. Without it, the whole application is replaced.
+	UpdateMask *fieldmaskpb.FieldMask
+}
+
+// fromGRPCApplication converts a gRPC application given by a client
+func fromGRPCApplication(app *Application) *kubernetes.Application {
+	return &kubernetes.Application{
+		Name:            app.Name,
+		Namespace:       app.Namespace,
+		Labels:          app.Labels,
+		Status:          kubernetes.ApplicationStatus(app.Status),
+		SyncStatus:      kubernetes.SyncStatus(app.SyncStatus),
+		SyncPolicy:      toSyncPolicy(app),
+		DependsOn:       app.DependsOn,
+		Source:          toSource(app.Source),
+		ResourceVersion: app.ResourceVersion,
+	}
+}
+
+// applyUpdateMask returns a copy of existing with the fields listed in paths
+// taken from update, along with the resource version of update
+func applyUpdateMask(existing, update *kubernetes.Application, paths []string) (*kubernetes.Application, error) {
+	result := *existing
+	result.ResourceVersion = update.ResourceVersion
+	if result.Source != nil {
+		source := *result.Source
+		result.Source = &source
+	}
+	updateSource := update.Source
+	if updateSource == nil {
+		updateSource = &kubernetes.ApplicationSource{}
+	}
+	source := func() *kubernetes.ApplicationSource {
+		if result.Source == nil {
+			result.Source = &kubernetes.ApplicationSource{}
+		}
+		return result.Source
+	}
+
+	for _, path := range paths {
+		switch path {
+		case "namespace":
+			result.Namespace = update.Namespace
+		case "labels":
+			result.Labels = update.Labels
+		case "auto_sync":
+			result.SyncPolicy.AutoSync = update.SyncPolicy.AutoSync
+		case "self_heal":
+			result.SyncPolicy.SelfHeal = update.SyncPolicy.SelfHeal
+		case "prune":
+			result.SyncPolicy.Prune = update.SyncPolicy.Prune
+		case "depends_on":
+			result.DependsOn = update.DependsOn
+		case "source":
+			result.Source = update.Source
+		case "source.repo_url":
+			source().RepoURL = updateSource.RepoURL
+		case "source.revision":
+			source().Revision = updateSource.Revision
+		case "source.path":
+			source().Path = updateSource.Path
+		case "source.helm":
+			source().Helm = updateSource.Helm
+		case "source.kustomize":
+			source().Kustomize = updateSource.Kustomize
+		case "source.template":
+			source().Template = updateSource.Template
+		case "name", "status", "sync_status", "target_commit", "synced_commit", "resource_version":
+			return nil, fmt.Errorf("field %s cannot be updated", path)
+		default:
+			return nil, fmt.Errorf("unknown field %s", path)
+		}
+	}
+	return &result, nil
+}
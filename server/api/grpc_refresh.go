@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sysintelligent/devops-bridge/server/auth"
+	"github.com/sysintelligent/devops-bridge/server/autosync"
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RefreshApplicationRequest is a request to refresh an application
+type RefreshApplicationRequest struct {
+	// Name is the name of the application
+	Name string
+	// Sync also syncs the application if it opted in to auto-sync, which
+	// requires the sync verb
+	Sync bool
+}
+
+// RefreshApplication refreshes the sync status of an application now, and
+// syncs it if asked and it opted in to auto-sync
+func (s *applicationServiceServer) RefreshApplication(ctx context.Context, req *RefreshApplicationRequest) (*Application, error) {
+	if s.scheduler == nil {
+		return nil, status.Error(codes.Unimplemented, "Auto-sync is not enabled")
+	}
+	if user, _ := auth.UserFromContext(ctx); req.Sync && !s.authService.CanPerform(user, auth.VerbSync) {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	app, err := s.scheduler.RefreshNow(ctx, req.Name, req.Sync)
+	if err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			return nil, status.Errorf(codes.NotFound, "Application not found: %v", err)
+		}
+		if errors.Is(err, autosync.ErrNotLeader) {
+			return nil, status.Errorf(codes.Unavailable, "Only the leader syncs applications, retry to reach it: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to refresh application: %v", err)
+	}
+
+	// Convert to gRPC response
+	return toGRPCApplication(app), nil
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ResourceTree is the tree of Kubernetes objects that make up an application
+type ResourceTree struct {
+	// Roots are the objects managed by the application itself
+	Roots []*ResourceNode
+}
+
+// ResourceNode is a Kubernetes object in an application's resource tree
+type ResourceNode struct {
+	// Kind is the Kubernetes kind of the object
+	Kind string
+	// Name is the name of the object
+	Name string
+	// Namespace is the namespace of the object
+	Namespace string
+	// Health is the health of the object
+	Health string
+	// HealthMessage explains why the object is not healthy
+	HealthMessage string
+	// SyncStatus is the sync status of objects managed by the application
+	SyncStatus string
+	// Children are the objects owned by this object
+	Children []*ResourceNode
+}
+
+// GetApplicationResources returns the resource tree of an application
+func (s *applicationServiceServer) GetApplicationResources(ctx context.Context, req *ApplicationRequest) (*ResourceTree, error) {
+	// Get resource tree from Kubernetes
+	roots, err := s.k8sClient.GetApplicationResources(ctx, req.Name)
+	if err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			return nil, status.Errorf(codes.NotFound, "Application not found: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to get resources: %v", err)
+	}
+
+	// Convert to gRPC response
+	return &ResourceTree{Roots: toGRPCResourceNodes(roots)}, nil
+}
+
+// toGRPCResourceNodes converts resource nodes to their gRPC representation
+func toGRPCResourceNodes(nodes []*kubernetes.ResourceNode) []*ResourceNode {
+	var result []*ResourceNode
+	for _, node := range nodes {
+		result = append(result, &ResourceNode{
+			Kind:          node.Kind,
+			Name:          node.Name,
+			Namespace:     node.Namespace,
+			Health:        string(node.Health),
+			HealthMessage: node.HealthMessage,
+			SyncStatus:    string(node.SyncStatus),
+			Children:      toGRPCResourceNodes(node.Children),
+		})
+	}
+	return result
+}
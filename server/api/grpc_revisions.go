@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sysintelligent/devops-bridge/server/auth"
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"github.com/sysintelligent/devops-bridge/server/revisions"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// revisionMessageMetadata carries an optional message describing a change
+const revisionMessageMetadata = "x-revision-message"
+
+// RevisionRequest is a request for a specific revision of an application
+type RevisionRequest struct {
+	// Name is the name of the application
+	Name string
+	// Number is the revision number; zero means the previous revision for rollbacks
+	Number int32
+}
+
+// RevisionDiffRequest is a request to compare two revisions of an application
+type RevisionDiffRequest struct {
+	// Name is the name of the application
+	Name string
+	// From is the revision to compare against
+	From int32
+	// To is the revision to compare
+	To int32
+}
+
+// RevisionList is a list of revisions
+type RevisionList struct {
+	// Revisions is the list of revisions, newest first
+	Revisions []*Revision
+}
+
+// Revision is an immutable snapshot of an application's spec
+type Revision struct {
+	// Number is the revision number
+	Number int32
+	// Reason is what created the revision: create, update, sync or rollback
+	Reason string
+	// Message describes the change
+	Message string
+	// Author is the ID of the user who made the change
+	Author string
+	// AuthorName is the name of the user who made the change
+	AuthorName string
+	// CreatedAt is when the revision was recorded
+	CreatedAt *timestamppb.Timestamp
+	// Spec is the application as it was after the change
+	Spec *Application
+}
+
+// RevisionDiff lists the fields that differ between two revisions
+type RevisionDiff struct {
+	// Changes are the changed fields
+	Changes []*RevisionChange
+}
+
+// RevisionChange is a single field that differs between two revisions
+type RevisionChange struct {
+	// Field is the dotted JSON name of the field
+	Field string
+	// From is the JSON-encoded old value
+	From string
+	// To is the JSON-encoded new value
+	To string
+}
+
+// recordRevision records a revision of app on behalf of the calling user
+func (s *applicationServiceServer) recordRevision(ctx context.Context, app kubernetes.Application, reason revisions.Reason) {
+	if s.revisions == nil {
+		return
+	}
+
+	var message string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(revisionMessageMetadata); len(values) > 0 {
+			message = values[0]
+		}
+	}
+
+	var author, authorName string
+	if user, ok := auth.UserFromContext(ctx); ok {
+		author, authorName = user.ID, user.Name
+	}
+	s.revisions.Record(app, reason, author, authorName, message)
+}
+
+// ListApplicationRevisions returns the revisions of an application, newest first
+func (s *applicationServiceServer) ListApplicationRevisions(ctx context.Context, req *ApplicationRequest) (*RevisionList, error) {
+	if s.revisions == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Revision history is not enabled")
+	}
+
+	// Convert to gRPC response
+	var result RevisionList
+	for _, revision := range s.revisions.List(req.Name) {
+		result.Revisions = append(result.Revisions, toGRPCRevision(revision))
+	}
+	return &result, nil
+}
+
+// GetApplicationRevision returns a single revision of an application
+func (s *applicationServiceServer) GetApplicationRevision(ctx context.Context, req *RevisionRequest) (*Revision, error) {
+	if s.revisions == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Revision history is not enabled")
+	}
+
+	revision, err := s.revisions.Get(req.Name, int(req.Number))
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "Revision not found: %v", err)
+	}
+	return toGRPCRevision(revision), nil
+}
+
+// DiffApplicationRevisions compares two revisions of an application
+func (s *applicationServiceServer) DiffApplicationRevisions(ctx context.Context, req *RevisionDiffRequest) (*RevisionDiff, error) {
+	if s.revisions == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Revision history is not enabled")
+	}
+
+	from, err := s.revisions.Get(req.Name, int(req.From))
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "Revision not found: %v", err)
+	}
+	to, err := s.revisions.Get(req.Name, int(req.To))
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "Revision not found: %v", err)
+	}
+
+	changes, err := revisions.Diff(from, to)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to compare revisions: %v", err)
+	}
+
+	// Convert to gRPC response, encoding values as JSON
+	var result RevisionDiff
+	for _, change := range changes {
+		result.Changes = append(result.Changes, &RevisionChange{
+			Field: change.Field,
+			From:  jsonString(change.From),
+			To:    jsonString(change.To),
+		})
+	}
+	return &result, nil
+}
+
+// RollbackApplicationToRevision restores the spec of a previous revision
+func (s *applicationServiceServer) RollbackApplicationToRevision(ctx context.Context, req *RevisionRequest) (*Application, error) {
+	if s.revisions == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Revision history is not enabled")
+	}
+
+	// Find the revision to roll back to
+	number := int(req.Number)
+	if number == 0 {
+		latest, err := s.revisions.Latest(req.Name)
+		if err != nil {
+			return nil, status.Errorf(codes.NotFound, "Revision not found: %v", err)
+		}
+		number = latest.Number - 1
+	}
+	target, err := s.revisions.Get(req.Name, number)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "Revision not found: %v", err)
+	}
+
+	// Restore the spec of the revision
+	app := target.Spec
+	if err := s.k8sClient.UpdateApplication(ctx, req.Name, &app); err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			return nil, status.Errorf(codes.NotFound, "Application not found: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to roll back application: %v", err)
+	}
+
+	// Record the rollback as a new revision
+	ctx = metadata.NewIncomingContext(ctx, withDefaultMetadata(ctx, revisionMessageMetadata, fmt.Sprintf("Rollback to revision %d", number)))
+	s.recordRevision(ctx, app, revisions.ReasonRollback)
+
+	return toGRPCApplication(&app), nil
+}
+
+// withDefaultMetadata returns the incoming metadata of ctx with key set to
+// value unless it is already present
+func withDefaultMetadata(ctx context.Context, key, value string) metadata.MD {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	if len(md.Get(key)) == 0 {
+		md.Set(key, value)
+	}
+	return md
+}
+
+// jsonString encodes a value as JSON for transport as a string
+func jsonString(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// toGRPCRevision converts a revision to its gRPC representation
+func toGRPCRevision(revision revisions.Revision) *Revision {
+	return &Revision{
+		Number:     int32(revision.Number),
+		Reason:     string(revision.Reason),
+		Message:    revision.Message,
+		Author:     revision.Author,
+		AuthorName: revision.AuthorName,
+		CreatedAt:  timestamppb.New(revision.CreatedAt),
+		Spec:       toGRPCApplication(&revision.Spec),
+	}
+}
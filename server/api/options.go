@@ -0,0 +1,98 @@
+package api
+
+import (
+	"log/slog"
+
+	"github.com/sysintelligent/devops-bridge/server/audit"
+	"github.com/sysintelligent/devops-bridge/server/autosync"
+	"github.com/sysintelligent/devops-bridge/server/batch"
+	"github.com/sysintelligent/devops-bridge/server/catalog"
+	"github.com/sysintelligent/devops-bridge/server/incidents"
+	"github.com/sysintelligent/devops-bridge/server/logging"
+	"github.com/sysintelligent/devops-bridge/server/metrics"
+	"github.com/sysintelligent/devops-bridge/server/revisions"
+)
+
+// Option enables an optional subsystem on the REST and gRPC APIs
+type Option func(*options)
+
+// options holds the optional subsystems shared by the REST and gRPC APIs
+type options struct {
+	incidents *incidents.Tracker
+	revisions *revisions.Store
+	audit     *audit.Logger
+	metrics   *metrics.Metrics
+	logger    *slog.Logger
+	scheduler *autosync.Scheduler
+	catalog   *catalog.Catalog
+	batch     *batch.Runner
+}
+
+// newOptions applies opts on top of the defaults
+func newOptions(opts []Option) options {
+	o := options{logger: logging.Discard()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithIncidents exposes the incidents recorded by tracker
+func WithIncidents(tracker *incidents.Tracker) Option {
+	return func(o *options) {
+		o.incidents = tracker
+	}
+}
+
+// WithRevisions records a revision in store for every application change
+// and exposes the revision history
+func WithRevisions(store *revisions.Store) Option {
+	return func(o *options) {
+		o.revisions = store
+	}
+}
+
+// WithAudit records every mutating REST call in logger and exposes the
+// recorded entries to admins
+func WithAudit(logger *audit.Logger) Option {
+	return func(o *options) {
+		o.audit = logger
+	}
+}
+
+// WithMetrics records REST request counts, latencies and auth failures in m
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithLogger writes access logs and request failures to logger
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithScheduler exposes manual refreshes through scheduler and its sync
+// interval in the settings
+func WithScheduler(scheduler *autosync.Scheduler) Option {
+	return func(o *options) {
+		o.scheduler = scheduler
+	}
+}
+
+// WithCatalog exposes the templates of catalog and lets developers create
+// applications from them
+func WithCatalog(templates *catalog.Catalog) Option {
+	return func(o *options) {
+		o.catalog = templates
+	}
+}
+
+// WithBatch exposes actions on many applications at once through runner
+func WithBatch(runner *batch.Runner) Option {
+	return func(o *options) {
+		o.batch = runner
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ifMatchMetadata is the gRPC metadata key equivalent to the If-Match header
+const ifMatchMetadata = "if-match"
+
+// etag returns the entity tag of an application, its quoted resource version
+func etag(app *kubernetes.Application) string {
+	return `"` + app.ResourceVersion + `"`
+}
+
+// setETag sets the ETag header of a response about app
+func setETag(w http.ResponseWriter, app *kubernetes.Application) {
+	w.Header().Set("ETag", etag(app))
+}
+
+// ifMatch reports whether an If-Match header, a list of entity tags or *,
+// matches the resource version of app. Weak tags are compared by value.
+func ifMatch(header string, app *kubernetes.Application) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag(app) || tag == app.ResourceVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchFromContext returns the if-match metadata of a gRPC call, if any
+func ifMatchFromContext(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(ifMatchMetadata); len(values) > 0 {
+			return strings.Join(values, ",")
+		}
+	}
+	return ""
+}
+
+// checkIfMatch evaluates the If-Match header of a request changing the
+// application called name. It returns the resource version the change must
+// apply to, empty without the header. If the precondition fails, it writes
+// the error response and returns false.
+func (h *RESTHandler) checkIfMatch(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return "", true
+	}
+	app, err := h.k8sClient.GetApplication(r.Context(), name)
+	if err != nil {
+		http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+		return "", false
+	}
+	if !ifMatch(header, app) {
+		http.Error(w, `{"error":"Application has been modified"}`, http.StatusPreconditionFailed)
+		return "", false
+	}
+	return app.ResourceVersion, true
+}
+
+// checkIfMatch evaluates the if-match metadata of a gRPC call changing the
+// application called name, like the If-Match header. It returns the resource
+// version the change must apply to, empty without the metadata, and a
+// FailedPrecondition error if the precondition fails.
+func (s *applicationServiceServer) checkIfMatch(ctx context.Context, name string) (string, error) {
+	header := ifMatchFromContext(ctx)
+	if header == "" {
+		return "", nil
+	}
+	app, err := s.k8sClient.GetApplication(ctx, name)
+	if err != nil {
+		return "", status.Errorf(codes.NotFound, "Application not found: %v", err)
+	}
+	if !ifMatch(header, app) {
+		return "", status.Errorf(codes.FailedPrecondition, "Application has been modified: resource version is %s", app.ResourceVersion)
+	}
+	return app.ResourceVersion, nil
+}
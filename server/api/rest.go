@@ -2,11 +2,19 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/sysintelligent/devops-bridge/server/audit"
 	"github.com/sysintelligent/devops-bridge/server/auth"
 	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"github.com/sysintelligent/devops-bridge/server/logging"
+	"github.com/sysintelligent/devops-bridge/server/revisions"
+	"github.com/sysintelligent/devops-bridge/server/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // RESTHandler handles REST API requests
@@ -14,58 +22,204 @@ type RESTHandler struct {
 	k8sClient   *kubernetes.Client
 	authService *auth.Service
 	routes      map[string]http.HandlerFunc
+	options
 }
 
 // NewRESTHandler creates a new REST API handler
-func NewRESTHandler(k8sClient *kubernetes.Client, authService *auth.Service) http.Handler {
+func NewRESTHandler(k8sClient *kubernetes.Client, authService *auth.Service, opts ...Option) http.Handler {
 	h := &RESTHandler{
 		k8sClient:   k8sClient,
 		authService: authService,
 		routes:      make(map[string]http.HandlerFunc),
+		options:     newOptions(opts),
 	}
 
 	// Register routes
 	h.routes["GET /applications"] = h.handleGetApplications
+	h.routes["GET /applications:graph"] = h.handleGetDependencyGraph
 	h.routes["POST /applications"] = h.handleCreateApplication
 	h.routes["GET /applications/{name}"] = h.handleGetApplication
 	h.routes["PUT /applications/{name}"] = h.handleUpdateApplication
+	h.routes["PATCH /applications/{name}"] = h.handlePatchApplication
 	h.routes["DELETE /applications/{name}"] = h.handleDeleteApplication
+	h.routes["GET /applications/{name}/logs"] = h.handleGetApplicationLogs
+	h.routes["GET /applications/{name}/events"] = h.handleGetApplicationEvents
+	h.routes["GET /applications/{name}/resources"] = h.handleGetApplicationResources
+	h.routes["GET /applications/{name}/manifests"] = h.handleGetApplicationManifests
+	h.routes["POST /applications/{name}/actions/{action}"] = h.handleApplicationAction
 	h.routes["GET /settings"] = h.handleGetSettings
 	h.routes["PUT /settings"] = h.handleUpdateSettings
 
+	// Register routes of optional subsystems
+	if h.incidents != nil {
+		h.registerIncidentRoutes()
+	}
+	if h.revisions != nil {
+		h.registerRevisionRoutes()
+	}
+	if h.audit != nil {
+		h.routes["GET /audit"] = h.handleGetAudit
+	}
+	if h.scheduler != nil {
+		h.routes["POST /applications/{name}/refresh"] = h.handleRefreshApplication
+	}
+	if h.catalog != nil {
+		h.registerTemplateRoutes()
+	}
+	if h.batch != nil {
+		h.routes["POST /applications:batch"] = h.handleBatchApplications
+	}
+
 	return h
 }
 
 // ServeHTTP implements the http.Handler interface
 func (h *RESTHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	recorder := &statusRecorder{ResponseWriter: w}
+	w = recorder
+
+	// Find route handler
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	pattern, handler := h.findRoute(r.Method + " /" + path)
+	route := strings.TrimPrefix(pattern, r.Method+" ")
+	if route == "" {
+		route = "unmatched"
+	}
+
+	// Continue the trace started by the caller, if any
+	ctx, span := tracing.Start(tracing.ExtractHTTP(r.Context(), r), "RESTHandler.ServeHTTP",
+		attribute.String("http.request.method", r.Method),
+		attribute.String("http.route", route),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.Code()))
+		span.End()
+	}()
+
+	// Assign a request ID and write an access log entry once served
+	requestID := logging.RequestIDOrNew(r.Header.Get(logging.RequestIDHeader))
+	ctx = logging.NewContext(ctx, requestID)
+	w.Header().Set(logging.RequestIDHeader, requestID)
+	defer func() {
+		h.logger.LogAttrs(ctx, slog.LevelInfo, "HTTP request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", route),
+			slog.Int("status", recorder.Code()),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+	}()
+	r = r.WithContext(ctx)
+
+	// Record request metrics by route pattern to keep the number of series bounded
+	if h.metrics != nil {
+		defer func() {
+			h.metrics.ObserveHTTP(route, r.Method, recorder.Code(), time.Since(start))
+		}()
+	}
+
 	// Set common headers
 	w.Header().Set("Content-Type", "application/json")
 
+	// Record mutating calls in the audit log, including those denied below
+	var user *auth.User
+	if h.audit != nil && audit.IsMutatingHTTP(r.Method) {
+		done := h.audit.BeginHTTP(r)
+		defer func() { done(user, recorder.Code()) }()
+	}
+
 	// Authenticate request
+	_, authSpan := tracing.Start(ctx, "auth")
 	user, err := h.authService.AuthenticateRequest(r)
 	if err != nil {
+		tracing.End(authSpan, err)
+		logging.AddAttrs(ctx, slog.String("error", err.Error()))
+		h.authFailure("unauthenticated")
 		http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
 		return
 	}
 
+	// Make the user available to handlers and logs
+	r = r.WithContext(auth.WithUser(r.Context(), user))
+
 	// Check if user has permission to access the resource
-	if !h.authService.HasPermission(user, r.Method, r.URL.Path) {
+	allowed := h.authService.HasPermission(user, r.Method, r.URL.Path)
+	authSpan.SetAttributes(attribute.String("enduser.id", user.ID), attribute.Bool("auth.allowed", allowed))
+	authSpan.End()
+	if !allowed {
+		h.logger.DebugContext(ctx, "Permission denied")
+		h.authFailure("forbidden")
 		http.Error(w, `{"error":"Forbidden"}`, http.StatusForbidden)
 		return
 	}
 
-	// Find route handler
-	path := strings.TrimPrefix(r.URL.Path, "/")
-	routeKey := r.Method + " /" + path
+	if handler == nil {
+		// Route not found
+		http.Error(w, `{"error":"Not Found"}`, http.StatusNotFound)
+		return
+	}
+
+	// Span the handler separately from auth and routing
+	handlerCtx, handlerSpan := tracing.Start(r.Context(), pattern)
+	defer handlerSpan.End()
+	handler(w, r.WithContext(handlerCtx))
+}
+
+// findRoute returns the pattern and handler of the route matching a route
+// key, or empty values if no route matches
+func (h *RESTHandler) findRoute(key string) (string, http.HandlerFunc) {
 	for pattern, handler := range h.routes {
-		if matchRoute(pattern, routeKey) {
-			handler(w, r)
-			return
+		if matchRoute(pattern, key) {
+			return pattern, handler
 		}
 	}
+	return "", nil
+}
+
+// authFailure counts a rejected request, if metrics are enabled
+func (h *RESTHandler) authFailure(reason string) {
+	if h.metrics != nil {
+		h.metrics.AuthFailure("http", reason)
+	}
+}
 
-	// Route not found
-	http.Error(w, `{"error":"Not Found"}`, http.StatusNotFound)
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+// WriteHeader records the status code
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.code == 0 {
+		s.code = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Write records an implicit 200 status code
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.code == 0 {
+		s.code = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers flush through the recorder
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Code returns the recorded status code, 200 if none was written
+func (s *statusRecorder) Code() int {
+	if s.code == 0 {
+		return http.StatusOK
+	}
+	return s.code
 }
 
 // matchRoute checks if a route pattern matches a route key
@@ -95,8 +249,9 @@ func matchRoute(pattern, key string) bool {
 // handleGetApplications handles GET /applications
 func (h *RESTHandler) handleGetApplications(w http.ResponseWriter, r *http.Request) {
 	// Get applications from Kubernetes
-	apps, err := h.k8sClient.GetApplications()
+	apps, err := h.k8sClient.GetApplications(r.Context())
 	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to get applications", "error", err)
 		http.Error(w, `{"error":"Failed to get applications"}`, http.StatusInternalServerError)
 		return
 	}
@@ -109,18 +264,30 @@ func (h *RESTHandler) handleGetApplications(w http.ResponseWriter, r *http.Reque
 func (h *RESTHandler) handleCreateApplication(w http.ResponseWriter, r *http.Request) {
 	// Parse request body
 	var app kubernetes.Application
-	if err := json.NewDecoder(r.Body).Decode(&app); err != nil {
-		http.Error(w, `{"error":"Invalid request body"}`, http.StatusBadRequest)
+	if err := decodeStrict(r.Body, &app); err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
 	// Create application in Kubernetes
-	if err := h.k8sClient.CreateApplication(&app); err != nil {
+	if err := h.k8sClient.CreateApplication(r.Context(), &app); err != nil {
+		if writeValidationError(w, err) || writeSourceError(w, err) {
+			return
+		}
+		if errors.Is(err, kubernetes.ErrApplicationExists) {
+			http.Error(w, `{"error":"Application already exists"}`, http.StatusConflict)
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "Failed to create application", "error", err)
 		http.Error(w, `{"error":"Failed to create application"}`, http.StatusInternalServerError)
 		return
 	}
 
+	// Record the new application
+	h.recordRevision(r, app, revisions.ReasonCreate)
+
 	// Return success
+	setETag(w, &app)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(app)
 }
@@ -131,13 +298,14 @@ func (h *RESTHandler) handleGetApplication(w http.ResponseWriter, r *http.Reques
 	name := extractPathParam(r.URL.Path, "applications")
 
 	// Get application from Kubernetes
-	app, err := h.k8sClient.GetApplication(name)
+	app, err := h.k8sClient.GetApplication(r.Context(), name)
 	if err != nil {
 		http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
 		return
 	}
 
 	// Return application as JSON
+	setETag(w, app)
 	json.NewEncoder(w).Encode(app)
 }
 
@@ -148,18 +316,46 @@ func (h *RESTHandler) handleUpdateApplication(w http.ResponseWriter, r *http.Req
 
 	// Parse request body
 	var app kubernetes.Application
-	if err := json.NewDecoder(r.Body).Decode(&app); err != nil {
-		http.Error(w, `{"error":"Invalid request body"}`, http.StatusBadRequest)
+	if err := decodeStrict(r.Body, &app); err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
+	// A resource version in the body must be current, as must the one in
+	// If-Match, if any
+	version, ok := h.checkIfMatch(w, r, name)
+	if !ok {
+		return
+	}
+	conflict := http.StatusConflict
+	if app.ResourceVersion == "" && version != "" {
+		app.ResourceVersion = version
+		conflict = http.StatusPreconditionFailed
+	}
+
 	// Update application in Kubernetes
-	if err := h.k8sClient.UpdateApplication(name, &app); err != nil {
+	if err := h.k8sClient.UpdateApplication(r.Context(), name, &app); err != nil {
+		if writeValidationError(w, err) || writeSourceError(w, err) {
+			return
+		}
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, kubernetes.ErrConflict) {
+			http.Error(w, `{"error":"Application has been modified"}`, conflict)
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "Failed to update application", "error", err)
 		http.Error(w, `{"error":"Failed to update application"}`, http.StatusInternalServerError)
 		return
 	}
 
+	// Record the change
+	h.recordRevision(r, app, revisions.ReasonUpdate)
+
 	// Return success
+	setETag(w, &app)
 	json.NewEncoder(w).Encode(app)
 }
 
@@ -168,8 +364,23 @@ func (h *RESTHandler) handleDeleteApplication(w http.ResponseWriter, r *http.Req
 	// Extract application name from URL
 	name := extractPathParam(r.URL.Path, "applications")
 
+	// Delete only the version given in If-Match, if any
+	version, ok := h.checkIfMatch(w, r, name)
+	if !ok {
+		return
+	}
+
 	// Delete application from Kubernetes
-	if err := h.k8sClient.DeleteApplication(name); err != nil {
+	if err := h.k8sClient.DeleteApplication(r.Context(), name, version); err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, kubernetes.ErrConflict) {
+			http.Error(w, `{"error":"Application has been modified"}`, http.StatusPreconditionFailed)
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "Failed to delete application", "error", err)
 		http.Error(w, `{"error":"Failed to delete application"}`, http.StatusInternalServerError)
 		return
 	}
@@ -186,6 +397,9 @@ func (h *RESTHandler) handleGetSettings(w http.ResponseWriter, r *http.Request)
 		"clusterName":  "default",
 		"syncInterval": 300,
 	}
+	if h.scheduler != nil {
+		settings["syncInterval"] = int(h.scheduler.Interval().Seconds())
+	}
 
 	// Return settings as JSON
 	json.NewEncoder(w).Encode(settings)
@@ -200,7 +414,16 @@ func (h *RESTHandler) handleUpdateSettings(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Placeholder for updating settings
+	// The sync interval, in seconds, applies to the auto-sync scheduler
+	if value, ok := settings["syncInterval"]; ok && h.scheduler != nil {
+		seconds, ok := value.(float64)
+		if !ok || h.scheduler.SetInterval(time.Duration(seconds*float64(time.Second))) != nil {
+			http.Error(w, `{"error":"syncInterval must be a positive number of seconds"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Placeholder for updating other settings
 	// In a real implementation, this would update settings in a database or config file
 
 	// Return success
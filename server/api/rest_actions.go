@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// handleApplicationAction handles POST /applications/{name}/actions/{action}
+func (h *RESTHandler) handleApplicationAction(w http.ResponseWriter, r *http.Request) {
+	// Extract application name and action from URL
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	name, action := parts[1], parts[3]
+
+	// Parse request body, which may be empty
+	var req kubernetes.RolloutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, `{"error":"Invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	req.Action = kubernetes.RolloutAction(action)
+
+	// Perform the action on the application's workloads
+	results, err := h.k8sClient.RolloutApplication(r.Context(), name, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, kubernetes.ErrApplicationNotFound):
+			http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+		case errors.Is(err, kubernetes.ErrInvalidRolloutAction):
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		case errors.Is(err, kubernetes.ErrNoWorkloads):
+			http.Error(w, `{"error":"Application has no matching workloads"}`, http.StatusNotFound)
+		case errors.Is(err, kubernetes.ErrNoRevision):
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusConflict)
+		default:
+			h.logger.ErrorContext(r.Context(), "Failed to apply action", "action", action, "error", err)
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, "Failed to "+action+" application: "+err.Error()), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Return the per-workload results
+	json.NewEncoder(w).Encode(results)
+}
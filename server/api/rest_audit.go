@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sysintelligent/devops-bridge/server/audit"
+)
+
+// handleGetAudit handles GET /audit
+//
+// Query parameters:
+//   - actor: only return calls made by this user ID
+//   - resource: only return calls to resources starting with this prefix
+//   - since: only return calls made after this RFC 3339 time or duration ago, e.g. 1h
+//   - limit: return at most this many entries
+func (h *RESTHandler) handleGetAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := audit.Query{
+		Actor:    query.Get("actor"),
+		Resource: query.Get("resource"),
+	}
+
+	if since := query.Get("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			q.Since = t
+		} else if d, err := time.ParseDuration(since); err == nil && d >= 0 {
+			q.Since = time.Now().Add(-d)
+		} else {
+			http.Error(w, `{"error":"since must be an RFC 3339 time or a duration"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			http.Error(w, `{"error":"limit must be a positive integer"}`, http.StatusBadRequest)
+			return
+		}
+		q.Limit = n
+	}
+
+	// Return audit entries as JSON, newest first
+	json.NewEncoder(w).Encode(h.audit.Query(q))
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sysintelligent/devops-bridge/server/auth"
+	"github.com/sysintelligent/devops-bridge/server/autosync"
+	"github.com/sysintelligent/devops-bridge/server/batch"
+)
+
+// handleBatchApplications handles POST /applications:batch
+func (h *RESTHandler) handleBatchApplications(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var req batch.Request
+	if err := decodeStrict(r.Body, &req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	// Act on the selected applications on behalf of the requesting user
+	user, _ := auth.UserFromContext(r.Context())
+	resp, err := h.batch.Run(r.Context(), req, batch.Actor{ID: user.ID, Name: user.Name})
+	if err != nil {
+		if errors.Is(err, batch.ErrInvalidRequest) {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, autosync.ErrNotLeader) {
+			// Another replica behind the same service may be the leader
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, `{"error":"Only the leader syncs applications, retry to reach it"}`, http.StatusServiceUnavailable)
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "Failed to run batch", "action", req.Action, "error", err)
+		http.Error(w, `{"error":"Failed to run batch"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Return the per-application results
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// handleGetApplicationEvents handles GET /applications/{name}/events
+func (h *RESTHandler) handleGetApplicationEvents(w http.ResponseWriter, r *http.Request) {
+	// Extract application name from URL
+	name := extractPathParam(r.URL.Path, "applications")
+
+	// Get events from Kubernetes, optionally filtered by type
+	events, err := h.k8sClient.GetApplicationEvents(r.Context(), name, r.URL.Query().Get("type"))
+	if err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "Failed to get events", "error", err)
+		http.Error(w, `{"error":"Failed to get events"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Return events as JSON
+	json.NewEncoder(w).Encode(events)
+}
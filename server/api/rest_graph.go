@@ -0,0 +1,20 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleGetDependencyGraph handles GET /applications:graph
+func (h *RESTHandler) handleGetDependencyGraph(w http.ResponseWriter, r *http.Request) {
+	// Get the graph of all applications
+	graph, err := h.k8sClient.GetDependencyGraph()
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to get dependency graph", "error", err)
+		http.Error(w, `{"error":"Failed to get dependency graph"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Return the graph as JSON
+	json.NewEncoder(w).Encode(graph)
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sysintelligent/devops-bridge/server/auth"
+	"github.com/sysintelligent/devops-bridge/server/incidents"
+)
+
+// incidentActionRequest is the optional body of incident actions
+type incidentActionRequest struct {
+	Note string `json:"note"`
+}
+
+// registerIncidentRoutes registers the /incidents routes
+func (h *RESTHandler) registerIncidentRoutes() {
+	h.routes["GET /incidents"] = h.handleGetIncidents
+	h.routes["GET /incidents/{id}"] = h.handleGetIncident
+	h.routes["POST /incidents/{id}/ack"] = h.handleAcknowledgeIncident
+	h.routes["POST /incidents/{id}/resolve"] = h.handleResolveIncident
+	h.routes["POST /incidents/{id}/notes"] = h.handleAddIncidentNote
+}
+
+// handleGetIncidents handles GET /incidents
+func (h *RESTHandler) handleGetIncidents(w http.ResponseWriter, r *http.Request) {
+	// Filter by query parameters
+	query := r.URL.Query()
+	filter := incidents.Filter{
+		Application: query.Get("application"),
+		State:       incidents.State(query.Get("state")),
+	}
+
+	// Return incidents as JSON
+	json.NewEncoder(w).Encode(h.incidents.List(filter))
+}
+
+// handleGetIncident handles GET /incidents/{id}
+func (h *RESTHandler) handleGetIncident(w http.ResponseWriter, r *http.Request) {
+	// Extract incident ID from URL
+	id := extractPathParam(r.URL.Path, "incidents")
+
+	incident, err := h.incidents.Get(id)
+	if err != nil {
+		writeIncidentError(w, err)
+		return
+	}
+
+	// Return incident as JSON
+	json.NewEncoder(w).Encode(incident)
+}
+
+// handleAcknowledgeIncident handles POST /incidents/{id}/ack
+func (h *RESTHandler) handleAcknowledgeIncident(w http.ResponseWriter, r *http.Request) {
+	h.handleIncidentAction(w, r, h.incidents.Acknowledge)
+}
+
+// handleResolveIncident handles POST /incidents/{id}/resolve
+func (h *RESTHandler) handleResolveIncident(w http.ResponseWriter, r *http.Request) {
+	h.handleIncidentAction(w, r, h.incidents.Resolve)
+}
+
+// handleAddIncidentNote handles POST /incidents/{id}/notes
+func (h *RESTHandler) handleAddIncidentNote(w http.ResponseWriter, r *http.Request) {
+	h.handleIncidentAction(w, r, func(id, actor, note string) (incidents.Incident, error) {
+		if strings.TrimSpace(note) == "" {
+			return incidents.Incident{}, errEmptyNote
+		}
+		return h.incidents.AddNote(id, actor, note)
+	})
+}
+
+// errEmptyNote is returned when adding a note without text
+var errEmptyNote = errors.New("note must not be empty")
+
+// handleIncidentAction decodes the optional action body and applies action
+// to the incident on behalf of the authenticated user
+func (h *RESTHandler) handleIncidentAction(w http.ResponseWriter, r *http.Request, action func(id, actor, note string) (incidents.Incident, error)) {
+	// Extract incident ID from URL
+	id := extractPathParam(r.URL.Path, "incidents")
+
+	// Parse request body, which may be empty
+	var req incidentActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, `{"error":"Invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	incident, err := action(id, user.ID, req.Note)
+	if err != nil {
+		writeIncidentError(w, err)
+		return
+	}
+
+	// Return the updated incident
+	json.NewEncoder(w).Encode(incident)
+}
+
+// writeIncidentError maps incident errors to HTTP responses
+func writeIncidentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, incidents.ErrIncidentNotFound):
+		http.Error(w, `{"error":"Incident not found"}`, http.StatusNotFound)
+	case errors.Is(err, incidents.ErrIncidentResolved):
+		http.Error(w, `{"error":"Incident is already resolved"}`, http.StatusConflict)
+	case errors.Is(err, errEmptyNote):
+		http.Error(w, `{"error":"Note must not be empty"}`, http.StatusBadRequest)
+	default:
+		http.Error(w, `{"error":"Failed to update incident"}`, http.StatusInternalServerError)
+	}
+}
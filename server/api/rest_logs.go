@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// handleGetApplicationLogs handles GET /applications/{name}/logs
+//
+// Query parameters:
+//   - container: only return logs of containers with this name
+//   - since: only return lines newer than this duration, e.g. 10m
+//   - tailLines: only return this many lines per container
+//   - follow: keep streaming new lines
+//   - prefix: prefix each line with [pod/container] (default true)
+//
+// Lines are written as chunked text/plain, or as server-sent events with a
+// JSON payload when the client accepts text/event-stream.
+func (h *RESTHandler) handleGetApplicationLogs(w http.ResponseWriter, r *http.Request) {
+	// Extract application name from URL
+	name := extractPathParam(r.URL.Path, "applications")
+
+	// Parse query parameters
+	opts, prefix, err := parseLogOptions(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.k8sClient.GetApplication(r.Context(), name); err != nil {
+		http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+		return
+	}
+
+	sse := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	flusher, _ := w.(http.Flusher)
+
+	// Stream log lines as they arrive
+	started := false
+	err = h.k8sClient.StreamApplicationLogs(r.Context(), name, opts, func(line kubernetes.LogLine) error {
+		started = true
+		var err error
+		switch {
+		case sse:
+			data, _ := json.Marshal(line)
+			_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+		case prefix:
+			_, err = fmt.Fprintf(w, "[%s/%s] %s\n", line.Pod, line.Container, line.Line)
+		default:
+			_, err = fmt.Fprintln(w, line.Line)
+		}
+		if err == nil && opts.Follow && flusher != nil {
+			flusher.Flush()
+		}
+		return err
+	})
+	if err != nil && r.Context().Err() == nil {
+		h.logger.ErrorContext(r.Context(), "Failed to stream logs", "application", name, "error", err)
+		if !started {
+			w.Header().Set("Content-Type", "application/json")
+			if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+				http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, `{"error":"Failed to get logs"}`, http.StatusBadGateway)
+			return
+		}
+		// Headers are already sent, so report the error in-band
+		if sse {
+			fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error())
+		} else {
+			fmt.Fprintf(w, "error: %v\n", err)
+		}
+	}
+}
+
+// parseLogOptions parses the log query parameters of a request
+func parseLogOptions(r *http.Request) (kubernetes.LogOptions, bool, error) {
+	query := r.URL.Query()
+	opts := kubernetes.LogOptions{
+		Container: query.Get("container"),
+	}
+
+	if since := query.Get("since"); since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil || d < 0 {
+			return opts, false, errors.New("since must be a positive duration")
+		}
+		opts.Since = d
+	}
+	if tail := query.Get("tailLines"); tail != "" {
+		n, err := strconv.ParseInt(tail, 10, 64)
+		if err != nil || n < 0 {
+			return opts, false, errors.New("tailLines must be a positive integer")
+		}
+		opts.TailLines = n
+	}
+	if follow := query.Get("follow"); follow != "" {
+		b, err := strconv.ParseBool(follow)
+		if err != nil {
+			return opts, false, errors.New("follow must be a boolean")
+		}
+		opts.Follow = b
+	}
+
+	prefix := true
+	if p := query.Get("prefix"); p != "" {
+		b, err := strconv.ParseBool(p)
+		if err != nil {
+			return opts, false, errors.New("prefix must be a boolean")
+		}
+		prefix = b
+	}
+
+	return opts, prefix, nil
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// handleGetApplicationManifests handles GET /applications/{name}/manifests
+func (h *RESTHandler) handleGetApplicationManifests(w http.ResponseWriter, r *http.Request) {
+	// Extract application name from URL
+	name := extractPathParam(r.URL.Path, "applications")
+
+	// Render the desired objects from the application source
+	manifests, err := h.k8sClient.GetApplicationManifests(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, kubernetes.ErrNoSource) {
+			http.Error(w, `{"error":"Application has no source"}`, http.StatusBadRequest)
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "Failed to render application manifests", "error", err)
+		http.Error(w, `{"error":"Failed to render application manifests"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Return manifests as JSON
+	json.NewEncoder(w).Encode(manifests)
+}
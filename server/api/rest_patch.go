@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"github.com/sysintelligent/devops-bridge/server/revisions"
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+)
+
+const (
+	// mergePatchType is the media type of JSON Merge Patch (RFC 7396)
+	mergePatchType = "application/merge-patch+json"
+	// jsonPatchType is the media type of JSON Patch (RFC 6902)
+	jsonPatchType = "application/json-patch+json"
+)
+
+// patchAttempts is how often a patch is applied again when the application
+// changes while it is being patched
+const patchAttempts = 3
+
+// errInvalidPatch is returned for patches that cannot be applied
+var errInvalidPatch = errors.New("invalid patch")
+
+// handlePatchApplication handles PATCH /applications/{name}
+func (h *RESTHandler) handlePatchApplication(w http.ResponseWriter, r *http.Request) {
+	// Extract application name from URL
+	name := extractPathParam(r.URL.Path, "applications")
+
+	// Parse request body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != mergePatchType && mediaType != jsonPatchType {
+		w.Header().Set("Accept-Patch", mergePatchType+", "+jsonPatchType)
+		http.Error(w, `{"error":"Content-Type must be application/merge-patch+json or application/json-patch+json"}`, http.StatusUnsupportedMediaType)
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"Invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	// The patch must apply to the version in If-Match, if any
+	version, ok := h.checkIfMatch(w, r, name)
+	if !ok {
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		app, err := h.k8sClient.GetApplication(r.Context(), name)
+		if err != nil {
+			http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+			return
+		}
+		if version != "" && app.ResourceVersion != version {
+			http.Error(w, `{"error":"Application has been modified"}`, http.StatusPreconditionFailed)
+			return
+		}
+
+		patched, err := patchApplication(app, patch, mediaType)
+		if err != nil {
+			if !writeValidationError(w, err) {
+				http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+			}
+			return
+		}
+
+		// Unless the patch sets the resource version itself, it applies to
+		// the version it was computed from
+		explicit := patched.ResourceVersion != app.ResourceVersion
+		err = h.k8sClient.UpdateApplication(r.Context(), name, patched)
+		if errors.Is(err, kubernetes.ErrConflict) && !explicit && version == "" && attempt < patchAttempts {
+			// Changed since it was read, so patch the new version
+			continue
+		}
+		if err != nil {
+			if writeValidationError(w, err) || writeSourceError(w, err) {
+				return
+			}
+			if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+				http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, kubernetes.ErrConflict) {
+				conflict := http.StatusConflict
+				if version != "" {
+					conflict = http.StatusPreconditionFailed
+				}
+				http.Error(w, `{"error":"Application has been modified"}`, conflict)
+				return
+			}
+			h.logger.ErrorContext(r.Context(), "Failed to patch application", "error", err)
+			http.Error(w, `{"error":"Failed to patch application"}`, http.StatusInternalServerError)
+			return
+		}
+
+		// Record the change
+		h.recordRevision(r, *patched, revisions.ReasonUpdate)
+
+		// Return success
+		setETag(w, patched)
+		json.NewEncoder(w).Encode(patched)
+		return
+	}
+}
+
+// patchApplication applies a JSON Merge Patch or JSON Patch to app and
+// decodes the result as strictly as an application in a request body
+func patchApplication(app *kubernetes.Application, patch []byte, mediaType string) (*kubernetes.Application, error) {
+	original, err := json.Marshal(app)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []byte
+	switch mediaType {
+	case mergePatchType:
+		result, err = jsonpatch.MergePatch(original, patch)
+	case jsonPatchType:
+		var operations jsonpatch.Patch
+		if operations, err = jsonpatch.DecodePatch(patch); err == nil {
+			result, err = operations.Apply(original)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidPatch, err)
+	}
+
+	var patched kubernetes.Application
+	if err := decodeStrict(bytes.NewReader(result), &patched); err != nil {
+		return nil, err
+	}
+	return &patched, nil
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/sysintelligent/devops-bridge/server/auth"
+	"github.com/sysintelligent/devops-bridge/server/autosync"
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// handleRefreshApplication handles POST /applications/{name}/refresh
+func (h *RESTHandler) handleRefreshApplication(w http.ResponseWriter, r *http.Request) {
+	// Extract application name from URL
+	name := extractPathParam(r.URL.Path, "applications")
+
+	// Syncing, which may prune objects, requires the sync verb
+	var withSync bool
+	if value := r.URL.Query().Get("sync"); value != "" {
+		var err error
+		if withSync, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, `{"error":"sync must be true or false"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	if user, _ := auth.UserFromContext(r.Context()); withSync && !h.authService.CanPerform(user, auth.VerbSync) {
+		http.Error(w, `{"error":"Forbidden"}`, http.StatusForbidden)
+		return
+	}
+
+	// Refresh the sync status now, syncing if asked and the application opted in
+	app, err := h.scheduler.RefreshNow(r.Context(), name, withSync)
+	if err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, autosync.ErrNotLeader) {
+			// Another replica behind the same service may be the leader
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, `{"error":"Only the leader syncs applications, retry to reach it"}`, http.StatusServiceUnavailable)
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "Failed to refresh application", "error", err)
+		http.Error(w, `{"error":"Failed to refresh application"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Return the refreshed application
+	json.NewEncoder(w).Encode(app)
+}
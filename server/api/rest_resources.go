@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// handleGetApplicationResources handles GET /applications/{name}/resources
+func (h *RESTHandler) handleGetApplicationResources(w http.ResponseWriter, r *http.Request) {
+	// Extract application name from URL
+	name := extractPathParam(r.URL.Path, "applications")
+
+	// Get resource tree from Kubernetes
+	tree, err := h.k8sClient.GetApplicationResources(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "Failed to get resources", "error", err)
+		http.Error(w, `{"error":"Failed to get resources"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Return resource tree as JSON
+	if tree == nil {
+		tree = []*kubernetes.ResourceNode{}
+	}
+	json.NewEncoder(w).Encode(tree)
+}
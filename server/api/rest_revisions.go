@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sysintelligent/devops-bridge/server/auth"
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"github.com/sysintelligent/devops-bridge/server/revisions"
+)
+
+// revisionMessageHeader carries an optional message describing a change
+const revisionMessageHeader = "X-Revision-Message"
+
+// registerRevisionRoutes registers the /applications/{name}/revisions routes
+func (h *RESTHandler) registerRevisionRoutes() {
+	h.routes["GET /applications/{name}/revisions"] = h.handleGetRevisions
+	h.routes["GET /applications/{name}/revisions/{n}"] = h.handleGetRevision
+	h.routes["GET /applications/{name}/revisions/{n}/diff"] = h.handleDiffRevisions
+	h.routes["POST /applications/{name}/rollback"] = h.handleRollbackApplication
+}
+
+// recordRevision records a revision of app on behalf of the requesting user
+func (h *RESTHandler) recordRevision(r *http.Request, app kubernetes.Application, reason revisions.Reason) {
+	if h.revisions == nil {
+		return
+	}
+	user, _ := auth.UserFromContext(r.Context())
+	h.revisions.Record(app, reason, user.ID, user.Name, r.Header.Get(revisionMessageHeader))
+}
+
+// handleGetRevisions handles GET /applications/{name}/revisions
+func (h *RESTHandler) handleGetRevisions(w http.ResponseWriter, r *http.Request) {
+	// Extract application name from URL
+	name := extractPathParam(r.URL.Path, "applications")
+
+	// Return revisions as JSON, newest first
+	json.NewEncoder(w).Encode(h.revisions.List(name))
+}
+
+// handleGetRevision handles GET /applications/{name}/revisions/{n}
+func (h *RESTHandler) handleGetRevision(w http.ResponseWriter, r *http.Request) {
+	// Extract application name and revision number from URL
+	name := extractPathParam(r.URL.Path, "applications")
+	number, err := revisionNumber(r.URL.Path)
+	if err != nil {
+		http.Error(w, `{"error":"Invalid revision number"}`, http.StatusBadRequest)
+		return
+	}
+
+	revision, err := h.revisions.Get(name, number)
+	if err != nil {
+		http.Error(w, `{"error":"Revision not found"}`, http.StatusNotFound)
+		return
+	}
+
+	// Return revision as JSON
+	json.NewEncoder(w).Encode(revision)
+}
+
+// handleDiffRevisions handles GET /applications/{name}/revisions/{n}/diff,
+// comparing revision n with the revision given by the from query parameter,
+// which defaults to the revision before n
+func (h *RESTHandler) handleDiffRevisions(w http.ResponseWriter, r *http.Request) {
+	// Extract application name and revision numbers from URL
+	name := extractPathParam(r.URL.Path, "applications")
+	number, err := revisionNumber(r.URL.Path)
+	if err != nil {
+		http.Error(w, `{"error":"Invalid revision number"}`, http.StatusBadRequest)
+		return
+	}
+	from := number - 1
+	if f := r.URL.Query().Get("from"); f != "" {
+		if from, err = strconv.Atoi(f); err != nil {
+			http.Error(w, `{"error":"Invalid revision number"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	to, err := h.revisions.Get(name, number)
+	if err != nil {
+		http.Error(w, `{"error":"Revision not found"}`, http.StatusNotFound)
+		return
+	}
+	base, err := h.revisions.Get(name, from)
+	if err != nil {
+		http.Error(w, `{"error":"Revision not found"}`, http.StatusNotFound)
+		return
+	}
+
+	changes, err := revisions.Diff(base, to)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to compare revisions", "error", err)
+		http.Error(w, `{"error":"Failed to compare revisions"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Return the changed fields as JSON
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":    from,
+		"to":      number,
+		"changes": changes,
+	})
+}
+
+// handleRollbackApplication handles POST /applications/{name}/rollback?to=n.
+// Without the to parameter, the application is rolled back to the revision
+// before the latest one.
+func (h *RESTHandler) handleRollbackApplication(w http.ResponseWriter, r *http.Request) {
+	// Extract application name from URL
+	name := extractPathParam(r.URL.Path, "applications")
+
+	// Find the revision to roll back to
+	var target revisions.Revision
+	var err error
+	if to := r.URL.Query().Get("to"); to != "" {
+		number, convErr := strconv.Atoi(to)
+		if convErr != nil {
+			http.Error(w, `{"error":"Invalid revision number"}`, http.StatusBadRequest)
+			return
+		}
+		target, err = h.revisions.Get(name, number)
+	} else {
+		var latest revisions.Revision
+		if latest, err = h.revisions.Latest(name); err == nil {
+			target, err = h.revisions.Get(name, latest.Number-1)
+		}
+	}
+	if err != nil {
+		http.Error(w, `{"error":"Revision not found"}`, http.StatusNotFound)
+		return
+	}
+
+	// Restore the spec of the revision
+	app := target.Spec
+	if err := h.k8sClient.UpdateApplication(r.Context(), name, &app); err != nil {
+		if errors.Is(err, kubernetes.ErrApplicationNotFound) {
+			http.Error(w, `{"error":"Application not found"}`, http.StatusNotFound)
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "Failed to roll back application", "error", err)
+		http.Error(w, `{"error":"Failed to roll back application"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Record the rollback as a new revision
+	if r.Header.Get(revisionMessageHeader) == "" {
+		r.Header.Set(revisionMessageHeader, "Rollback to revision "+strconv.Itoa(target.Number))
+	}
+	h.recordRevision(r, app, revisions.ReasonRollback)
+
+	// Return the rolled back application
+	json.NewEncoder(w).Encode(app)
+}
+
+// revisionNumber extracts the revision number from a
+// /applications/{name}/revisions/{n} path
+func revisionNumber(path string) (int, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 4 {
+		return 0, errors.New("missing revision number")
+	}
+	return strconv.Atoi(parts[3])
+}
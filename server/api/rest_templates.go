@@ -0,0 +1,233 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sysintelligent/devops-bridge/server/catalog"
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"github.com/sysintelligent/devops-bridge/server/revisions"
+	"github.com/sysintelligent/devops-bridge/server/sources"
+)
+
+// templateApplicationRequest is the body of POST /templates/{name}/applications
+// and POST /templates/{name}/render
+type templateApplicationRequest struct {
+	Name       string                `json:"name"`
+	Namespace  string                `json:"namespace"`
+	Labels     map[string]string     `json:"labels,omitempty"`
+	Parameters map[string]string     `json:"parameters"`
+	SyncPolicy kubernetes.SyncPolicy `json:"syncPolicy"`
+	DependsOn  []string              `json:"dependsOn,omitempty"`
+}
+
+// application returns the application the request creates from template
+func (req *templateApplicationRequest) application(template string) *kubernetes.Application {
+	namespace := req.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	return &kubernetes.Application{
+		Name:       req.Name,
+		Namespace:  namespace,
+		Labels:     req.Labels,
+		SyncPolicy: req.SyncPolicy,
+		DependsOn:  req.DependsOn,
+		Source: &kubernetes.ApplicationSource{
+			Template: &kubernetes.TemplateSource{Name: template, Parameters: req.Parameters},
+		},
+	}
+}
+
+// registerTemplateRoutes registers the /templates routes
+func (h *RESTHandler) registerTemplateRoutes() {
+	h.routes["GET /templates"] = h.handleGetTemplates
+	h.routes["POST /templates"] = h.handleCreateTemplate
+	h.routes["GET /templates/{name}"] = h.handleGetTemplate
+	h.routes["PUT /templates/{name}"] = h.handleUpdateTemplate
+	h.routes["DELETE /templates/{name}"] = h.handleDeleteTemplate
+	h.routes["POST /templates/{name}/render"] = h.handleRenderTemplate
+	h.routes["POST /templates/{name}/applications"] = h.handleCreateTemplateApplication
+}
+
+// handleGetTemplates handles GET /templates
+func (h *RESTHandler) handleGetTemplates(w http.ResponseWriter, r *http.Request) {
+	// Return templates as JSON
+	json.NewEncoder(w).Encode(h.catalog.List())
+}
+
+// handleCreateTemplate handles POST /templates
+func (h *RESTHandler) handleCreateTemplate(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var template catalog.Template
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		http.Error(w, `{"error":"Invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.catalog.Create(template)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	// Return success
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// handleGetTemplate handles GET /templates/{name}
+func (h *RESTHandler) handleGetTemplate(w http.ResponseWriter, r *http.Request) {
+	// Extract template name from URL
+	name := extractPathParam(r.URL.Path, "templates")
+
+	template, err := h.catalog.Get(name)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	// Return template as JSON
+	json.NewEncoder(w).Encode(template)
+}
+
+// handleUpdateTemplate handles PUT /templates/{name}
+func (h *RESTHandler) handleUpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	// Extract template name from URL
+	name := extractPathParam(r.URL.Path, "templates")
+
+	// Parse request body
+	var template catalog.Template
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		http.Error(w, `{"error":"Invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.catalog.Update(name, template)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	// Return success
+	json.NewEncoder(w).Encode(updated)
+}
+
+// handleDeleteTemplate handles DELETE /templates/{name}
+func (h *RESTHandler) handleDeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	// Extract template name from URL
+	name := extractPathParam(r.URL.Path, "templates")
+
+	if err := h.catalog.Delete(name); err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	// Return success
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRenderTemplate handles POST /templates/{name}/render, which previews
+// the manifests of an application created from the template
+func (h *RESTHandler) handleRenderTemplate(w http.ResponseWriter, r *http.Request) {
+	// Extract template name from URL
+	name := extractPathParam(r.URL.Path, "templates")
+
+	// Parse request body
+	var req templateApplicationRequest
+	if err := decodeStrict(r.Body, &req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	app := req.application(name)
+	if err := app.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	objects, commit, err := sources.RenderTemplate(h.catalog, app)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	// Return manifests as JSON
+	json.NewEncoder(w).Encode(kubernetes.Manifests{Commit: commit, Objects: objects})
+}
+
+// handleCreateTemplateApplication handles POST /templates/{name}/applications
+func (h *RESTHandler) handleCreateTemplateApplication(w http.ResponseWriter, r *http.Request) {
+	// Extract template name from URL
+	name := extractPathParam(r.URL.Path, "templates")
+
+	// Parse request body
+	var req templateApplicationRequest
+	if err := decodeStrict(r.Body, &req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	app := req.application(name)
+	if err := app.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	// Create application in Kubernetes, which validates the parameters
+	// and renders the manifests
+	if err := h.k8sClient.CreateApplication(r.Context(), app); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, kubernetes.ErrInvalidSource) {
+			writeTemplateError(w, err)
+			return
+		}
+		if errors.Is(err, kubernetes.ErrApplicationExists) {
+			http.Error(w, `{"error":"Application already exists"}`, http.StatusConflict)
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "Failed to create application", "error", err)
+		http.Error(w, `{"error":"Failed to create application"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Record the new application
+	h.recordRevision(r, *app, revisions.ReasonCreate)
+
+	// Return success
+	setETag(w, app)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(app)
+}
+
+// writeSourceError writes the response to an application whose source was
+// rejected and reports whether err was one
+func writeSourceError(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, kubernetes.ErrInvalidSource) {
+		return false
+	}
+	if errors.Is(err, catalog.ErrTemplateNotFound) || errors.Is(err, kubernetes.ErrNoManifestSource) {
+		// The template is part of the body, not of the URL
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return true
+	}
+	writeTemplateError(w, err)
+	return true
+}
+
+// writeTemplateError maps catalog errors to HTTP responses
+func writeTemplateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, catalog.ErrTemplateNotFound):
+		http.Error(w, `{"error":"Template not found"}`, http.StatusNotFound)
+	case errors.Is(err, catalog.ErrTemplateExists):
+		http.Error(w, `{"error":"Template already exists"}`, http.StatusConflict)
+	case errors.Is(err, catalog.ErrInvalidTemplate), errors.Is(err, catalog.ErrInvalidParameters):
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+	default:
+		// The manifests of the template don't render with these parameters
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusUnprocessableEntity)
+	}
+}
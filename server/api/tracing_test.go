@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sysintelligent/devops-bridge/server/auth"
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"github.com/sysintelligent/devops-bridge/server/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// testKubeconfig points at an API server that is never reached, as the
+// application store is in memory
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:1
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: test
+`
+
+// newTestClient creates a client with the seeded applications
+func newTestClient(t *testing.T) *kubernetes.Client {
+	t.Helper()
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := kubernetes.NewClient(slog.New(slog.NewTextHandler(io.Discard, nil)), kubeconfig, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestRESTSpanTree(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(exporter)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewRESTHandler(newTestClient(t), auth.NewService(logger, auth.Options{Disabled: true}), WithLogger(logger))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/applications/frontend", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /applications/frontend = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub, len(spans))
+	for _, span := range spans {
+		if got := span.SpanContext.TraceID().String(); got != traceID {
+			t.Errorf("span %s has trace ID %s, want the propagated %s", span.Name, got, traceID)
+		}
+		byName[span.Name] = span
+	}
+
+	// Each span and its expected parent, empty for the remote caller
+	for _, tt := range []struct {
+		name, parent string
+	}{
+		{"RESTHandler.ServeHTTP", ""},
+		{"auth", "RESTHandler.ServeHTTP"},
+		{"GET /applications/{name}", "RESTHandler.ServeHTTP"},
+		{"kubernetes.Client.GetApplication", "GET /applications/{name}"},
+	} {
+		span, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("no span %s among %d spans", tt.name, len(spans))
+			continue
+		}
+		if tt.parent == "" {
+			if !span.Parent.IsRemote() || span.Parent.SpanID().String() != "00f067aa0ba902b7" {
+				t.Errorf("span %s has parent %s, want the remote caller", tt.name, span.Parent.SpanID())
+			}
+			continue
+		}
+		parent, ok := byName[tt.parent]
+		if ok && span.Parent.SpanID() != parent.SpanContext.SpanID() {
+			t.Errorf("span %s is not a child of %s", tt.name, tt.parent)
+		}
+	}
+}
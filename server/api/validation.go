@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errInvalidBody is returned for request bodies that are not a single JSON value
+var errInvalidBody = errors.New("invalid request body")
+
+// validationResponse is the body of a response to an invalid application
+type validationResponse struct {
+	Error  string                  `json:"error"`
+	Fields []kubernetes.FieldError `json:"fields"`
+}
+
+// decodeStrict decodes a JSON request body into v, rejecting unknown fields.
+// Unknown fields and values of the wrong type are reported as a
+// *kubernetes.ValidationError.
+func decodeStrict(body io.Reader, v interface{}) error {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil && dec.More() {
+		return errInvalidBody
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &typeErr):
+		return &kubernetes.ValidationError{Fields: []kubernetes.FieldError{
+			{Field: typeErr.Field, Message: "must be of type " + typeErr.Type.String()},
+		}}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// The decoder has no error type for unknown fields
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &kubernetes.ValidationError{Fields: []kubernetes.FieldError{
+			{Field: field, Message: "unknown field"},
+		}}
+	}
+	return errInvalidBody
+}
+
+// writeValidationError writes the response to an invalid request body or
+// application and reports whether err was one
+func writeValidationError(w http.ResponseWriter, err error) bool {
+	var validationErr *kubernetes.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		data, _ := json.Marshal(validationResponse{Error: "Invalid application", Fields: validationErr.Fields})
+		http.Error(w, string(data), http.StatusBadRequest)
+		return true
+	case errors.Is(err, errInvalidBody):
+		http.Error(w, `{"error":"Invalid request body"}`, http.StatusBadRequest)
+		return true
+	}
+	return false
+}
+
+// validationStatus converts an invalid application into an InvalidArgument
+// status with a field violation per invalid field
+func validationStatus(err *kubernetes.ValidationError) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(err.Fields))
+	for i, field := range err.Fields {
+		violations[i] = &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message}
+	}
+	st, detailsErr := status.New(codes.InvalidArgument, err.Error()).
+		WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailsErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return st.Err()
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry records a single mutating API call
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Transport is http or grpc
+	Transport string   `json:"transport"`
+	Actor     string   `json:"actor"`
+	ActorName string   `json:"actorName,omitempty"`
+	Groups    []string `json:"groups,omitempty"`
+	// Verb is the HTTP method or the gRPC method name
+	Verb string `json:"verb"`
+	// Resource is the HTTP path or the full gRPC method
+	Resource string `json:"resource"`
+	// BodySHA256 is the hex-encoded SHA-256 hash of the request body
+	BodySHA256 string `json:"bodySha256,omitempty"`
+	// Code is the HTTP status code or the gRPC status code
+	Code int `json:"code"`
+	// Result is the text of Code, e.g. Not Found or NotFound
+	Result    string  `json:"result"`
+	LatencyMs float64 `json:"latencyMs"`
+	SourceIP  string  `json:"sourceIp,omitempty"`
+}
+
+// Query narrows down the entries returned by Logger.Query
+type Query struct {
+	// Actor matches entries of a single actor
+	Actor string
+	// Resource matches entries whose resource starts with this prefix
+	Resource string
+	// Since matches entries recorded at or after this time
+	Since time.Time
+	// Limit caps the number of entries returned, if positive
+	Limit int
+}
+
+// Sink receives every audit entry
+type Sink interface {
+	// Write persists an entry
+	Write(entry Entry) error
+	// Close flushes pending entries and releases resources
+	Close() error
+}
+
+// Logger records audit entries to its sinks and keeps the most recent
+// entries in memory so they can be queried
+type Logger struct {
+	sinks  []Sink
+	logger *slog.Logger
+
+	mu       sync.RWMutex
+	recent   []Entry
+	next     int
+	capacity int
+}
+
+// NewLogger creates an audit logger that keeps up to capacity entries in
+// memory. Sink errors are reported to logger.
+func NewLogger(logger *slog.Logger, capacity int, sinks ...Sink) *Logger {
+	return &Logger{
+		sinks:    sinks,
+		logger:   logger,
+		capacity: capacity,
+	}
+}
+
+// Record stores an entry and writes it to all sinks
+func (l *Logger) Record(entry Entry) {
+	l.mu.Lock()
+	if len(l.recent) < l.capacity {
+		l.recent = append(l.recent, entry)
+	} else if l.capacity > 0 {
+		l.recent[l.next] = entry
+	}
+	if l.capacity > 0 {
+		l.next = (l.next + 1) % l.capacity
+	}
+	l.mu.Unlock()
+
+	for _, sink := range l.sinks {
+		if err := sink.Write(entry); err != nil {
+			l.logger.Error("Failed to write audit entry", "error", err)
+		}
+	}
+}
+
+// Query returns the recorded entries matching q, newest first
+func (l *Logger) Query(q Query) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := []Entry{}
+	for i := 1; i <= len(l.recent); i++ {
+		// Walk backwards from the newest entry
+		entry := l.recent[(l.next-i+len(l.recent))%len(l.recent)]
+		if q.Actor != "" && entry.Actor != q.Actor {
+			continue
+		}
+		if q.Resource != "" && !strings.HasPrefix(entry.Resource, q.Resource) {
+			continue
+		}
+		if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+			continue
+		}
+		result = append(result, entry)
+		if q.Limit > 0 && len(result) == q.Limit {
+			break
+		}
+	}
+	return result
+}
+
+// Len returns the number of entries kept in memory
+func (l *Logger) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.recent)
+}
+
+// Close closes all sinks
+func (l *Logger) Close() error {
+	var firstErr error
+	for _, sink := range l.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
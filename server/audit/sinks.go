@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// WriterSink writes entries as JSON lines to a writer
+type WriterSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewWriterSink creates a sink writing JSON lines to w, e.g. os.Stdout
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewFileSink creates a sink appending JSON lines to the file at path
+func NewFileSink(path string) (*WriterSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log file: %w", err)
+	}
+	return &WriterSink{w: f, closer: f}, nil
+}
+
+// Write writes an entry as a single JSON line
+func (s *WriterSink) Write(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(data)
+	return err
+}
+
+// Close closes the underlying file, if the sink owns one
+func (s *WriterSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// errWebhookQueueFull is returned when the webhook cannot keep up with entries
+var errWebhookQueueFull = errors.New("audit webhook queue is full, dropping entry")
+
+// WebhookSink posts each entry as JSON to a URL. Entries are delivered in the
+// background so a slow webhook does not delay API calls.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+	queue  chan Entry
+	done   chan struct{}
+	// errors receives delivery failures from the background goroutine
+	errors func(error)
+}
+
+// NewWebhookSink creates a sink posting entries to url. Delivery failures are
+// passed to onError, which may be nil.
+func NewWebhookSink(url string, onError func(error)) *WebhookSink {
+	if onError == nil {
+		onError = func(error) {}
+	}
+	s := &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		queue:  make(chan Entry, 1000),
+		done:   make(chan struct{}),
+		errors: onError,
+	}
+	go s.run()
+	return s
+}
+
+// Write queues an entry for delivery
+func (s *WebhookSink) Write(entry Entry) error {
+	select {
+	case s.queue <- entry:
+		return nil
+	default:
+		return errWebhookQueueFull
+	}
+}
+
+// Close delivers the queued entries and stops the sink
+func (s *WebhookSink) Close() error {
+	close(s.queue)
+	<-s.done
+	return nil
+}
+
+// run delivers queued entries until the sink is closed
+func (s *WebhookSink) run() {
+	defer close(s.done)
+	for entry := range s.queue {
+		if err := s.post(entry); err != nil {
+			s.errors(err)
+		}
+	}
+}
+
+// post delivers a single entry
+func (s *WebhookSink) post(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to post audit entry: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("audit webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sysintelligent/devops-bridge/server/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// readOnlyMethodPrefixes are the prefixes of gRPC methods that don't mutate anything
+var readOnlyMethodPrefixes = []string{"Get", "List", "Diff", "Watch"}
+
+// IsMutatingHTTP reports whether an HTTP method may change state
+func IsMutatingHTTP(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// IsMutatingGRPC reports whether a gRPC method may change state, judging by
+// its name, e.g. /api.ApplicationService/UpdateApplication
+func IsMutatingGRPC(fullMethod string) bool {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	for _, prefix := range readOnlyMethodPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// BeginHTTP starts recording an HTTP request. It is called before
+// authentication so denied requests are recorded too, and returns a function
+// that records the entry with the caller, nil if unauthenticated, and the
+// response status code once the request has been served.
+func (l *Logger) BeginHTTP(r *http.Request) func(user *auth.User, code int) {
+	start := time.Now()
+
+	// Hash the body and put it back for the handler
+	var bodyHash string
+	if r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err == nil && len(body) > 0 {
+			bodyHash = hashBytes(body)
+		}
+	}
+
+	return func(user *auth.User, code int) {
+		entry := newEntry("http", user, start)
+		entry.Verb = r.Method
+		entry.Resource = r.URL.Path
+		entry.BodySHA256 = bodyHash
+		entry.Code = code
+		entry.Result = http.StatusText(code)
+		entry.SourceIP = hostOnly(r.RemoteAddr)
+		l.Record(entry)
+	}
+}
+
+// GRPCInterceptor creates a gRPC interceptor that records mutating calls. It
+// must be chained before auth.GRPCAuthInterceptor so calls denied by
+// authentication or authorization are recorded too.
+func GRPCInterceptor(l *Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !IsMutatingGRPC(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		ctx, caller := auth.WithCaller(ctx)
+		resp, err := handler(ctx, req)
+
+		entry := newEntry("grpc", caller(), start)
+		entry.Verb = info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+		entry.Resource = info.FullMethod
+		entry.BodySHA256 = hashMessage(req)
+		code := status.Code(err)
+		entry.Code = int(code)
+		entry.Result = code.String()
+		entry.SourceIP = peerAddress(ctx)
+		l.Record(entry)
+
+		return resp, err
+	}
+}
+
+// newEntry creates an entry for a call by user that started at start
+func newEntry(transport string, user *auth.User, start time.Time) Entry {
+	entry := Entry{
+		Time:      start,
+		Transport: transport,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if user != nil {
+		entry.Actor = user.ID
+		entry.ActorName = user.Name
+		entry.Groups = user.Groups
+	}
+	return entry
+}
+
+// hashMessage hashes a gRPC request, using its protobuf encoding when
+// available and JSON otherwise
+func hashMessage(req interface{}) string {
+	var data []byte
+	var err error
+	if msg, ok := req.(proto.Message); ok {
+		data, err = proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	} else {
+		data, err = json.Marshal(req)
+	}
+	if err != nil {
+		return ""
+	}
+	return hashBytes(data)
+}
+
+// hashBytes returns the hex-encoded SHA-256 hash of data
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// peerAddress returns the IP address of the gRPC caller
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	return hostOnly(p.Addr.String())
+}
+
+// hostOnly strips the port from an address
+func hostOnly(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -2,13 +2,20 @@ package auth
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/sysintelligent/devops-bridge/server/logging"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -22,22 +29,144 @@ type User struct {
 	Token   string
 }
 
+// AdminGroup is the group whose members are admins, e.g. the organizational
+// unit of admin client certificates
+const AdminGroup = "admins"
+
+// contextKey is the type used for values stored in a request context
+type contextKey string
+
+// userContextKey is the context key under which the authenticated user is stored
+const userContextKey contextKey = "user"
+
+// WithUser returns a copy of ctx carrying the authenticated user. The user
+// ID is also added to the records logged for the request.
+func WithUser(ctx context.Context, user *User) context.Context {
+	logging.AddAttrs(ctx, slog.String("user_id", user.ID))
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// callerContextKey is the context key under which GRPCAuthInterceptor
+// records the caller for the interceptors chained before it
+const callerContextKey contextKey = "caller"
+
+// caller holds the user authenticated for a call
+type caller struct {
+	user *User
+}
+
+// WithCaller returns a copy of ctx in which GRPCAuthInterceptor records the
+// authenticated user, even if it denies the call, and a function returning
+// that user, or nil if authentication failed. It lets interceptors chained
+// before authentication, such as auditing, know the caller.
+func WithCaller(ctx context.Context) (context.Context, func() *User) {
+	c := &caller{}
+	return context.WithValue(ctx, callerContextKey, c), func() *User { return c.user }
+}
+
+// UserFromContext returns the authenticated user stored in ctx, if any
+func UserFromContext(ctx context.Context) (*User, bool) {
+	user, ok := ctx.Value(userContextKey).(*User)
+	return user, ok && user != nil
+}
+
+// Verb is an operation on applications that can be granted to groups
+// without giving them full admin privileges
+type Verb string
+
+const (
+	// VerbRestart allows restarting the workloads of an application
+	VerbRestart Verb = "restart"
+	// VerbScale allows scaling the workloads of an application
+	VerbScale Verb = "scale"
+	// VerbPause allows pausing the rollout of an application
+	VerbPause Verb = "pause"
+	// VerbResume allows resuming the rollout of an application
+	VerbResume Verb = "resume"
+	// VerbRollback allows rolling back the workloads of an application
+	VerbRollback Verb = "rollback"
+	// VerbSync allows refreshing an application with a sync, which may
+	// prune objects
+	VerbSync Verb = "sync"
+	// VerbInstantiate allows creating applications from catalog templates,
+	// in any namespace and with any sync policy
+	VerbInstantiate Verb = "instantiate"
+)
+
 // Service provides authentication and authorization services
 type Service struct {
 	// In a real implementation, this would have connections to OAuth providers,
 	// a database for user information, etc.
+
+	// grants maps group names to the verbs their members may perform
+	grants map[string][]Verb
+	opts   Options
+	logger *slog.Logger
 }
 
-// NewService creates a new auth service
-func NewService() *Service {
-	return &Service{}
+// Options configures how callers are authenticated
+type Options struct {
+	// Disabled treats every caller as an admin, for local development only
+	Disabled bool
+	// UserToken is the bearer token of the demo user
+	UserToken string
+	// AdminToken is the bearer token of the demo admin
+	AdminToken string
+}
+
+// NewService creates a new auth service. Rejected requests are logged to
+// logger at debug level.
+func NewService(logger *slog.Logger, opts Options) *Service {
+	return &Service{
+		opts:   opts,
+		logger: logger,
+		grants: map[string][]Verb{
+			// Developers can restart and scale their own applications
+			"users": {VerbRestart, VerbScale},
+			// Operators can additionally control and undo rollouts, sync, and
+			// create applications from templates
+			"operators": {VerbRestart, VerbScale, VerbPause, VerbResume, VerbRollback, VerbSync, VerbInstantiate},
+		},
+	}
+}
+
+// CanPerform checks if a user has been granted a verb through one of their groups
+func (s *Service) CanPerform(user *User, verb Verb) bool {
+	if user.IsAdmin {
+		return true
+	}
+
+	for _, group := range user.Groups {
+		for _, granted := range s.grants[group] {
+			if granted == verb {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // AuthenticateRequest authenticates an HTTP request
 func (s *Service) AuthenticateRequest(r *http.Request) (*User, error) {
-	// Get the Authorization header
-	authHeader := r.Header.Get("Authorization")
+	user, err := s.authenticate(r.Header.Get("Authorization"), r.TLS)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "Authentication failed", "path", r.URL.Path, "error", err)
+		return nil, err
+	}
+	return user, nil
+}
+
+// authenticate authenticates a caller by the value of its Authorization
+// header or, if there is none, by its verified TLS client certificate
+func (s *Service) authenticate(authHeader string, state *tls.ConnectionState) (*User, error) {
+	if s.opts.Disabled {
+		return anonymousUser(), nil
+	}
+
 	if authHeader == "" {
+		if state != nil && len(state.VerifiedChains) > 0 {
+			return userFromCertificate(state.VerifiedChains[0][0]), nil
+		}
 		return nil, errors.New("no authorization header")
 	}
 
@@ -54,7 +183,7 @@ func (s *Service) AuthenticateRequest(r *http.Request) (*User, error) {
 
 	// In a real implementation, this would validate the token with an OAuth provider
 	// For now, we'll just create a dummy user for demonstration purposes
-	if token == "demo-token" {
+	if token == s.opts.UserToken {
 		return &User{
 			ID:      "user-1",
 			Name:    "Demo User",
@@ -63,7 +192,7 @@ func (s *Service) AuthenticateRequest(r *http.Request) (*User, error) {
 			IsAdmin: false,
 			Token:   token,
 		}, nil
-	} else if token == "admin-token" {
+	} else if token == s.opts.AdminToken {
 		return &User{
 			ID:      "admin-1",
 			Name:    "Admin User",
@@ -77,6 +206,29 @@ func (s *Service) AuthenticateRequest(r *http.Request) (*User, error) {
 	return nil, errors.New("invalid token")
 }
 
+// userFromCertificate maps a verified client certificate to a user: the
+// common name becomes the user ID and name, and the organizational units
+// become the groups
+func userFromCertificate(cert *x509.Certificate) *User {
+	groups := append([]string(nil), cert.Subject.OrganizationalUnit...)
+	return &User{
+		ID:      cert.Subject.CommonName,
+		Name:    cert.Subject.CommonName,
+		Groups:  groups,
+		IsAdmin: slices.Contains(groups, AdminGroup),
+	}
+}
+
+// anonymousUser is the caller when authentication is disabled
+func anonymousUser() *User {
+	return &User{
+		ID:      "anonymous",
+		Name:    "Anonymous",
+		Groups:  []string{AdminGroup},
+		IsAdmin: true,
+	}
+}
+
 // HasPermission checks if a user has permission to access a resource
 func (s *Service) HasPermission(user *User, method, path string) bool {
 	// If the user is an admin, they have access to everything
@@ -97,11 +249,44 @@ func (s *Service) HasPermission(user *User, method, path string) bool {
 		return true
 	}
 
+	// User can refresh the sync status of applications; syncing while
+	// refreshing is checked against the sync verb by the handler
+	if parts := strings.Split(strings.Trim(path, "/"), "/"); method == http.MethodPost &&
+		len(parts) == 3 && parts[0] == "applications" && parts[2] == "refresh" {
+		return true
+	}
+
 	// User can read settings
 	if method == http.MethodGet && path == "/settings" {
 		return true
 	}
 
+	// User can read incidents
+	if method == http.MethodGet && strings.HasPrefix(path, "/incidents") {
+		return true
+	}
+
+	// User can read templates and preview applications created from them;
+	// creating them is checked against the instantiate verb
+	if method == http.MethodGet && strings.HasPrefix(path, "/templates") {
+		return true
+	}
+	if parts := strings.Split(strings.Trim(path, "/"), "/"); method == http.MethodPost &&
+		len(parts) == 3 && parts[0] == "templates" {
+		switch parts[2] {
+		case "render":
+			return true
+		case "applications":
+			return s.CanPerform(user, VerbInstantiate)
+		}
+	}
+
+	// Application actions are checked against the verbs granted to the user
+	if parts := strings.Split(strings.Trim(path, "/"), "/"); method == http.MethodPost &&
+		len(parts) == 4 && parts[0] == "applications" && parts[2] == "actions" {
+		return s.CanPerform(user, Verb(parts[3]))
+	}
+
 	// All other operations require admin privileges
 	return false
 }
@@ -109,62 +294,38 @@ func (s *Service) HasPermission(user *User, method, path string) bool {
 // GRPCAuthInterceptor creates a gRPC interceptor for authentication
 func GRPCAuthInterceptor(authService *Service) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		// Get metadata from context
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, status.Errorf(codes.Unauthenticated, "metadata is not provided")
+		// Get the authorization token from the metadata
+		var authHeader string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				authHeader = values[0]
+			}
 		}
 
-		// Get authorization token
-		authHeader, ok := md["authorization"]
-		if !ok || len(authHeader) == 0 {
-			return nil, status.Errorf(codes.Unauthenticated, "authorization token is not provided")
+		var state *tls.ConnectionState
+		if p, ok := peer.FromContext(ctx); ok {
+			if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+				state = &info.State
+			}
 		}
 
-		// Check if it's a Bearer token
-		token := authHeader[0]
-		if !strings.HasPrefix(token, "Bearer ") {
-			return nil, status.Errorf(codes.Unauthenticated, "invalid authorization header format")
+		user, err := authService.authenticate(authHeader, state)
+		if err != nil {
+			authService.logger.DebugContext(ctx, "Authentication failed", "method", info.FullMethod, "error", err)
+			return nil, status.Errorf(codes.Unauthenticated, "%v", err)
 		}
-
-		// Extract the token
-		token = strings.TrimPrefix(token, "Bearer ")
-		if token == "" {
-			return nil, status.Errorf(codes.Unauthenticated, "empty token")
-		}
-
-		// In a real implementation, this would validate the token with an OAuth provider
-		// For now, we'll just create a dummy user for demonstration purposes
-		var user *User
-		if token == "demo-token" {
-			user = &User{
-				ID:      "user-1",
-				Name:    "Demo User",
-				Email:   "demo@example.com",
-				Groups:  []string{"users"},
-				IsAdmin: false,
-				Token:   token,
-			}
-		} else if token == "admin-token" {
-			user = &User{
-				ID:      "admin-1",
-				Name:    "Admin User",
-				Email:   "admin@example.com",
-				Groups:  []string{"users", "admins"},
-				IsAdmin: true,
-				Token:   token,
-			}
-		} else {
-			return nil, status.Errorf(codes.Unauthenticated, "invalid token")
+		if c, ok := ctx.Value(callerContextKey).(*caller); ok {
+			c.user = user
 		}
 
 		// Check if the user has permission to access the method
-		if !hasPermissionForMethod(user, info.FullMethod) {
+		if !authService.hasPermissionForMethod(user, info.FullMethod) {
+			authService.logger.DebugContext(ctx, "Permission denied", "user_id", user.ID, "method", info.FullMethod)
 			return nil, status.Errorf(codes.PermissionDenied, "permission denied")
 		}
 
 		// Add the user to the context
-		ctx = context.WithValue(ctx, "user", user)
+		ctx = WithUser(ctx, user)
 
 		// Call the handler
 		return handler(ctx, req)
@@ -172,7 +333,7 @@ func GRPCAuthInterceptor(authService *Service) grpc.UnaryServerInterceptor {
 }
 
 // hasPermissionForMethod checks if a user has permission to access a gRPC method
-func hasPermissionForMethod(user *User, method string) bool {
+func (s *Service) hasPermissionForMethod(user *User, method string) bool {
 	// If the user is an admin, they have access to everything
 	if user.IsAdmin {
 		return true
@@ -187,15 +348,40 @@ func hasPermissionForMethod(user *User, method string) bool {
 	}
 
 	// User can read applications
-	if strings.HasSuffix(method, "GetApplications") || strings.HasSuffix(method, "GetApplication") {
+	if strings.HasSuffix(method, "GetApplications") || strings.HasSuffix(method, "GetApplication") || strings.HasSuffix(method, "GetDependencyGraph") {
 		return true
 	}
 
+	// User can read application events, resources and revisions, and refresh applications
+	for _, suffix := range []string{"GetApplicationEvents", "GetApplicationResources", "GetApplicationManifests", "ListApplicationRevisions", "GetApplicationRevision", "DiffApplicationRevisions", "RefreshApplication"} {
+		if strings.HasSuffix(method, suffix) {
+			return true
+		}
+	}
+
 	// User can read settings
 	if strings.HasSuffix(method, "GetSettings") {
 		return true
 	}
 
+	// User can read incidents
+	if strings.HasSuffix(method, "ListIncidents") || strings.HasSuffix(method, "GetIncident") {
+		return true
+	}
+
+	// Application actions are checked against the verbs granted to the user
+	for verb, suffix := range map[Verb]string{
+		VerbRestart:  "RestartApplication",
+		VerbScale:    "ScaleApplication",
+		VerbPause:    "PauseApplication",
+		VerbResume:   "ResumeApplication",
+		VerbRollback: "RollbackApplication",
+	} {
+		if strings.HasSuffix(method, suffix) {
+			return s.CanPerform(user, verb)
+		}
+	}
+
 	// All other operations require admin privileges
 	return false
 }
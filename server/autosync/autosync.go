@@ -0,0 +1,422 @@
+package autosync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"github.com/sysintelligent/devops-bridge/server/revisions"
+)
+
+const (
+	// DefaultInterval is how often each application is refreshed
+	DefaultInterval = 300 * time.Second
+	// DefaultConcurrency is how many applications are refreshed at once
+	DefaultConcurrency = 4
+
+	// jitterFraction spreads refreshes over up to this fraction of the
+	// interval so applications are not all refreshed at once
+	jitterFraction = 0.1
+	// Failed and blocked refreshes are retried after initialBackoff,
+	// doubling up to maxBackoff
+	initialBackoff = 10 * time.Second
+	maxBackoff     = 5 * time.Minute
+	// refreshTimeout bounds a single refresh
+	refreshTimeout = time.Minute
+	// syncTimeout bounds a single sync, which may wait for several sync
+	// waves of the application to become healthy
+	syncTimeout = 30 * time.Minute
+	// tickInterval is how often the scheduler looks for due applications
+	tickInterval = time.Second
+)
+
+// ErrInvalidInterval is returned when setting an interval that is not positive
+var ErrInvalidInterval = errors.New("sync interval must be positive")
+
+// ErrNotLeader is returned when asking a replica that is not the leader to
+// sync, as only the leader syncs applications
+var ErrNotLeader = errors.New("this replica is not the leader")
+
+// appState is the schedule of a single application
+type appState struct {
+	// mu is held while the application is refreshed
+	mu sync.Mutex
+	// next is when the application is refreshed next
+	next time.Time
+	// failures counts the refreshes that failed in a row
+	failures int
+	// blocks counts the syncs blocked by dependencies in a row, and
+	// blockedBy lists the dependencies that blocked the last one
+	blocks    int
+	blockedBy []string
+}
+
+// Scheduler periodically refreshes the sync status of every application
+// and syncs those that are out of sync and opted in to auto-sync
+type Scheduler struct {
+	client    *kubernetes.Client
+	revisions *revisions.Store
+	logger    *slog.Logger
+	// slots limits the number of concurrent refreshes
+	slots chan struct{}
+	// running is set while Run runs, that is while this replica is the leader
+	running atomic.Bool
+
+	mu       sync.Mutex
+	interval time.Duration
+	apps     map[string]*appState
+}
+
+// NewScheduler creates a scheduler refreshing the applications of client
+// every interval, at most concurrency at a time. Syncs are recorded in
+// store, which may be nil.
+func NewScheduler(client *kubernetes.Client, store *revisions.Store, logger *slog.Logger, interval time.Duration, concurrency int) *Scheduler {
+	s := &Scheduler{
+		client:    client,
+		revisions: store,
+		logger:    logger,
+		slots:     make(chan struct{}, concurrency),
+		interval:  interval,
+		apps:      make(map[string]*appState),
+	}
+	client.AddStatusListener(s.unblock)
+	return s
+}
+
+// Interval returns the time between refreshes of an application
+func (s *Scheduler) Interval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interval
+}
+
+// SetInterval changes the time between refreshes of an application. It
+// takes effect after the next refresh of each application.
+func (s *Scheduler) SetInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return ErrInvalidInterval
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = interval
+	return nil
+}
+
+// Run refreshes applications as they become due until ctx is done. It is
+// meant to run on the leader only.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.logger.Info("Auto-sync scheduler started", "interval", s.Interval())
+	s.running.Store(true)
+	defer s.running.Store(false)
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, name := range s.due(ctx) {
+				// Leave due applications for the next tick if all slots are busy
+				select {
+				case s.slots <- struct{}{}:
+				default:
+					continue
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-s.slots }()
+					s.refresh(ctx, name, false, true)
+				}()
+			}
+		}
+	}
+}
+
+// RefreshNow refreshes an application at once and returns it. If withSync,
+// the application is also synced if its policy asks for it, which only the
+// leader does, so other replicas return ErrNotLeader. It waits for a
+// refresh of the application that is already running.
+func (s *Scheduler) RefreshNow(ctx context.Context, name string, withSync bool) (*kubernetes.Application, error) {
+	if withSync && !s.running.Load() {
+		return nil, ErrNotLeader
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-s.slots }()
+
+	return s.refresh(ctx, name, true, withSync)
+}
+
+// Leading reports whether this replica is the leader, and so may sync
+func (s *Scheduler) Leading() bool {
+	return s.running.Load()
+}
+
+// SyncNow syncs an application at once, whatever its policy, and records
+// the sync on behalf of author. Objects that are no longer desired are
+// pruned if the policy asks for it. Only the leader syncs, so other
+// replicas return ErrNotLeader. It waits for a refresh of the application
+// that is already running.
+func (s *Scheduler) SyncNow(ctx context.Context, name, author, authorName string) (*kubernetes.Application, error) {
+	if !s.running.Load() {
+		return nil, ErrNotLeader
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-s.slots }()
+
+	state := s.state(name)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+
+	app, err := s.client.GetApplication(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	app, err = s.client.SyncApplication(ctx, name, app.SyncPolicy.Prune)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Synced application", "application", name, "reason", "Manual", "prune", app.SyncPolicy.Prune,
+		"commit", app.SyncedCommit)
+
+	message := "Manual sync"
+	if app.SyncedCommit != "" {
+		message += " to " + app.SyncedCommit
+	}
+	if s.revisions != nil {
+		s.revisions.Record(*app, revisions.ReasonSync, author, authorName, message)
+	}
+	return app, nil
+}
+
+// due returns the applications whose refresh is due, and keeps the
+// schedule in line with the applications that exist
+func (s *Scheduler) due(ctx context.Context) []string {
+	apps, err := s.client.GetApplications(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get applications", "error", err)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	exists := make(map[string]bool, len(apps))
+	var due []string
+	for _, app := range apps {
+		exists[app.Name] = true
+		state, ok := s.apps[app.Name]
+		if !ok {
+			// Spread the first refreshes over the whole interval
+			state = &appState{next: now.Add(time.Duration(rand.Int64N(int64(s.interval))))}
+			s.apps[app.Name] = state
+		}
+		if !state.next.After(now) {
+			due = append(due, app.Name)
+		}
+	}
+	for name := range s.apps {
+		if !exists[name] {
+			delete(s.apps, name)
+		}
+	}
+	return due
+}
+
+// state returns the schedule of an application, creating it if needed
+func (s *Scheduler) state(name string) *appState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.apps[name]
+	if !ok {
+		state = &appState{}
+		s.apps[name] = state
+	}
+	return state
+}
+
+// refresh refreshes a single application and, if withSync, syncs it if
+// needed and schedules its next refresh. Scheduled refreshes skip
+// applications that are already being refreshed; manual ones wait for them.
+func (s *Scheduler) refresh(ctx context.Context, name string, manual, withSync bool) (*kubernetes.Application, error) {
+	state := s.state(name)
+	if manual {
+		state.mu.Lock()
+	} else if !state.mu.TryLock() {
+		return nil, nil
+	}
+	defer state.mu.Unlock()
+
+	app, err := s.reconcile(ctx, name, withSync)
+	if errors.Is(err, kubernetes.ErrApplicationNotFound) || !withSync {
+		// A refresh without sync leaves the auto-sync schedule as it is
+		return app, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var blocked *kubernetes.BlockedError
+	if errors.As(err, &blocked) {
+		// Not a failure: check again less and less often while the
+		// dependencies stay unhealthy, or as soon as they become healthy
+		state.failures = 0
+		state.blocks++
+		retryIn := backoff(state.blocks)
+		state.next = time.Now().Add(retryIn)
+		if !slices.Equal(state.blockedBy, blocked.BlockedBy) {
+			s.logger.Info("Waiting for dependencies", "application", name, "blockedBy", blocked.BlockedBy, "retryIn", retryIn)
+			state.blockedBy = blocked.BlockedBy
+		}
+		return app, nil
+	}
+	state.blocks, state.blockedBy = 0, nil
+	if err != nil {
+		state.failures++
+		retryIn := backoff(state.failures)
+		state.next = time.Now().Add(retryIn)
+		s.logger.Warn("Failed to refresh application", "application", name, "error", err,
+			"failures", state.failures, "retryIn", retryIn)
+		return nil, err
+	}
+	state.failures = 0
+	state.next = time.Now().Add(s.jitter(s.interval))
+	return app, nil
+}
+
+// backoff returns how long to wait after attempts failed or blocked
+// refreshes in a row, doubling from initialBackoff up to maxBackoff
+func backoff(attempts int) time.Duration {
+	delay := initialBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// unblock schedules the applications whose sync an application blocked for
+// a refresh once it becomes healthy. It is called on status changes.
+func (s *Scheduler) unblock(app kubernetes.Application, _ kubernetes.ApplicationStatus) {
+	if app.Status != kubernetes.ApplicationStatusHealthy {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, state := range s.apps {
+		if slices.Contains(state.blockedBy, app.Name) && state.next.After(now) {
+			state.next = now
+		}
+	}
+}
+
+// reconcile refreshes the sync status of an application and, if withSync,
+// syncs it if it is out of sync and its policy allows it. If its
+// dependencies block the sync, it returns the refreshed application along
+// with the error.
+func (s *Scheduler) reconcile(ctx context.Context, name string, withSync bool) (*kubernetes.Application, error) {
+	refreshCtx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+	app, err := s.client.RefreshApplication(refreshCtx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !withSync || app.SyncStatus != kubernetes.SyncStatusOutOfSync || !app.SyncPolicy.AutoSync {
+		return app, nil
+	}
+
+	// Without a change of the desired state since the last sync, either of
+	// the application or of its source, the live state drifted, which is
+	// only corrected with self-heal. Without revisions, changes of the
+	// application are unknown, so only a new commit of its source counts.
+	desiredChanged := app.TargetCommit != app.SyncedCommit
+	if s.revisions != nil {
+		latest, lastSync := s.revisionNumbers(name)
+		desiredChanged = desiredChanged || latest == 0 || latest > lastSync
+	}
+	if !desiredChanged && !app.SyncPolicy.SelfHeal {
+		return app, nil
+	}
+
+	syncCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+	synced, err := s.client.SyncApplication(syncCtx, name, app.SyncPolicy.Prune)
+	if errors.Is(err, kubernetes.ErrBlocked) {
+		return app, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync: %w", err)
+	}
+	app = synced
+
+	reason := "Auto-sync"
+	if !desiredChanged {
+		reason = "Self-heal"
+	}
+	s.logger.Info("Synced application", "application", name, "reason", reason, "prune", app.SyncPolicy.Prune,
+		"commit", app.SyncedCommit)
+
+	message := reason
+	if app.SyncedCommit != "" {
+		message += " to " + app.SyncedCommit
+	}
+	if s.revisions != nil {
+		s.revisions.Record(*app, revisions.ReasonSync, "system", "", message)
+	}
+	return app, nil
+}
+
+// revisionNumbers returns the numbers of the newest revision of an
+// application and of its newest sync revision, 0 if there is none. Syncs
+// are recorded whoever made them, so the last sync is known to whichever
+// replica leads next, as far as it has the revisions.
+func (s *Scheduler) revisionNumbers(name string) (latest, synced int) {
+	if s.revisions == nil {
+		return 0, 0
+	}
+	history := s.revisions.List(name)
+	if len(history) > 0 {
+		latest = history[0].Number
+	}
+	for _, revision := range history {
+		if revision.Reason == revisions.ReasonSync {
+			synced = revision.Number
+			break
+		}
+	}
+	return latest, synced
+}
+
+// jitter returns interval changed by a random amount of up to
+// jitterFraction in either direction
+func (s *Scheduler) jitter(interval time.Duration) time.Duration {
+	spread := int64(float64(interval) * jitterFraction)
+	if spread <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int64N(2*spread)-spread)
+}
@@ -0,0 +1,94 @@
+package autosync
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// testKubeconfig points at an API server that is never reached, as the
+// application store is in memory
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:1
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: test
+`
+
+// newTestClient creates a client with the seeded applications
+func newTestClient(t *testing.T) *kubernetes.Client {
+	t.Helper()
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := kubernetes.NewClient(slog.New(slog.NewTextHandler(io.Discard, nil)), kubeconfig, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestReconcileWithoutRevisions(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		selfHeal bool
+		want     kubernetes.SyncStatus
+	}{
+		// Whether the application changed since its last sync is unknown,
+		// so it is left out of sync
+		{"auto-sync", false, kubernetes.SyncStatusOutOfSync},
+		{"self-heal", true, kubernetes.SyncStatusSynced},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := newTestClient(t)
+			// The seeded database has no source and is out of sync
+			app, err := client.GetApplication(ctx, "database")
+			if err != nil {
+				t.Fatal(err)
+			}
+			app.SyncPolicy = kubernetes.SyncPolicy{AutoSync: true, SelfHeal: tt.selfHeal}
+			if err := client.UpdateApplication(ctx, "database", app); err != nil {
+				t.Fatal(err)
+			}
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			s := NewScheduler(client, nil, logger, DefaultInterval, DefaultConcurrency)
+			got, err := s.reconcile(ctx, "database", true)
+			if err != nil {
+				t.Fatalf("reconcile() error = %v", err)
+			}
+			if got.SyncStatus != tt.want {
+				t.Errorf("reconcile() sync status = %s, want %s", got.SyncStatus, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncNowRequiresLeader(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := NewScheduler(newTestClient(t), nil, logger, DefaultInterval, DefaultConcurrency)
+
+	// The scheduler only runs while this replica is the leader
+	if _, err := s.SyncNow(context.Background(), "database", "admin", "Admin"); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("SyncNow() error = %v, want %v", err, ErrNotLeader)
+	}
+}
@@ -0,0 +1,384 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sysintelligent/devops-bridge/server/autosync"
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// DefaultConcurrency is how many applications are acted on at once
+	// unless a request asks for fewer or more
+	DefaultConcurrency = 4
+	// MaxConcurrency bounds the concurrency a request may ask for
+	MaxConcurrency = 16
+
+	// waveTimeout bounds how long a sync waits for the applications of a
+	// dependency wave to finish progressing before syncing the next wave
+	waveTimeout = 2 * time.Minute
+)
+
+// ErrInvalidRequest is returned for batch requests that cannot be run
+var ErrInvalidRequest = errors.New("invalid batch request")
+
+// Action is what a batch does to each of its applications
+type Action string
+
+const (
+	// ActionSync syncs the applications, pruning if their policy asks for it
+	ActionSync Action = "sync"
+	// ActionRestart restarts the workloads of the applications
+	ActionRestart Action = "restart"
+	// ActionDelete deletes the applications
+	ActionDelete Action = "delete"
+)
+
+// Request selects applications and the action to perform on them. The
+// applications are either listed by name, or selected by a label selector
+// and/or namespace.
+type Request struct {
+	Action Action `json:"action"`
+	// Names lists the applications explicitly
+	Names []string `json:"names,omitempty"`
+	// Selector is a label selector, e.g. team=payments,tier!=frontend
+	Selector string `json:"selector,omitempty"`
+	// Namespace restricts the selection to applications in this namespace
+	Namespace string `json:"namespace,omitempty"`
+	// DryRun reports the applications that would be acted on without
+	// changing anything
+	DryRun bool `json:"dryRun,omitempty"`
+	// Concurrency is how many applications are acted on at once
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// ResultStatus is the outcome of a batch action on a single application
+type ResultStatus string
+
+const (
+	// ResultSucceeded means the action was performed
+	ResultSucceeded ResultStatus = "Succeeded"
+	// ResultFailed means the action failed, see the message
+	ResultFailed ResultStatus = "Failed"
+	// ResultSkipped means there was nothing to do, e.g. no workloads to
+	// restart, or the application is blocked by its dependencies
+	ResultSkipped ResultStatus = "Skipped"
+	// ResultDryRun means the action would have been performed
+	ResultDryRun ResultStatus = "DryRun"
+)
+
+// Result is the outcome of a batch action on a single application
+type Result struct {
+	Name      string       `json:"name"`
+	Namespace string       `json:"namespace,omitempty"`
+	Status    ResultStatus `json:"status"`
+	Message   string       `json:"message,omitempty"`
+}
+
+// Response holds the outcome per application, ordered by name
+type Response struct {
+	Action    Action   `json:"action"`
+	DryRun    bool     `json:"dryRun"`
+	Results   []Result `json:"results"`
+	Succeeded int      `json:"succeeded"`
+	Failed    int      `json:"failed"`
+	Skipped   int      `json:"skipped"`
+}
+
+// Actor is who a batch is run on behalf of, recorded with the syncs it does
+type Actor struct {
+	ID   string
+	Name string
+}
+
+// Runner performs actions on many applications at once
+type Runner struct {
+	client    *kubernetes.Client
+	scheduler *autosync.Scheduler
+	logger    *slog.Logger
+}
+
+// NewRunner creates a runner acting on the applications of client. Syncs
+// go through scheduler, so they are recorded and do not overlap with
+// auto-syncs.
+func NewRunner(client *kubernetes.Client, scheduler *autosync.Scheduler, logger *slog.Logger) *Runner {
+	return &Runner{
+		client:    client,
+		scheduler: scheduler,
+		logger:    logger,
+	}
+}
+
+// Run performs the action of req on every selected application, at most
+// req.Concurrency at a time, and waits for all of them. Syncs run in
+// dependency waves, each once the previous one settled. Failures of single
+// applications are reported in their results; an error is only returned
+// for invalid requests, and autosync.ErrNotLeader for syncs on a replica
+// that is not the leader.
+func (r *Runner) Run(ctx context.Context, req Request, actor Actor) (*Response, error) {
+	if err := req.check(); err != nil {
+		return nil, err
+	}
+	if req.Action == ActionSync && !req.DryRun && !r.scheduler.Leading() {
+		return nil, autosync.ErrNotLeader
+	}
+	concurrency := req.Concurrency
+	if concurrency == 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	targets, results, err := r.selectApplications(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Syncs run in dependency waves, so dependencies are synced first
+	waves := [][]int{make([]int, 0, len(targets))}
+	if req.Action == ActionSync {
+		if waves, err = r.dependencyWaves(targets); err != nil {
+			return nil, err
+		}
+	} else {
+		for i, app := range targets {
+			if app != nil {
+				waves[0] = append(waves[0], i)
+			}
+		}
+	}
+
+	slots := make(chan struct{}, concurrency)
+	for number, wave := range waves {
+		var wg sync.WaitGroup
+		for _, i := range wave {
+			app := targets[i]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				select {
+				case slots <- struct{}{}:
+				case <-ctx.Done():
+					results[i] = Result{Name: app.Name, Namespace: app.Namespace, Status: ResultFailed, Message: ctx.Err().Error()}
+					return
+				}
+				defer func() { <-slots }()
+				results[i] = r.act(ctx, req, app, actor)
+				if len(waves) > 1 {
+					results[i].Message = fmt.Sprintf("wave %d: %s", number, results[i].Message)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Applications of the next wave are blocked until their
+		// dependencies are healthy, so wait for this wave to settle
+		if !req.DryRun && number < len(waves)-1 {
+			r.awaitWave(ctx, targets, results, wave)
+		}
+	}
+
+	resp := &Response{Action: req.Action, DryRun: req.DryRun, Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case ResultSucceeded, ResultDryRun:
+			resp.Succeeded++
+		case ResultFailed:
+			resp.Failed++
+		case ResultSkipped:
+			resp.Skipped++
+		}
+	}
+	if !req.DryRun {
+		r.logger.InfoContext(ctx, "Ran batch", "action", req.Action, "applications", len(results),
+			"succeeded", resp.Succeeded, "failed", resp.Failed, "skipped", resp.Skipped, "user", actor.ID)
+	}
+	return resp, nil
+}
+
+// check validates a request
+func (req *Request) check() error {
+	switch req.Action {
+	case ActionSync, ActionRestart, ActionDelete:
+	case "":
+		return fmt.Errorf("%w: action is required", ErrInvalidRequest)
+	default:
+		return fmt.Errorf("%w: unknown action %q, expected sync, restart or delete", ErrInvalidRequest, req.Action)
+	}
+	if len(req.Names) > 0 && (req.Selector != "" || req.Namespace != "") {
+		return fmt.Errorf("%w: names cannot be combined with a selector or namespace", ErrInvalidRequest)
+	}
+	if len(req.Names) == 0 && req.Selector == "" && req.Namespace == "" {
+		// Guard against acting on every application by accident
+		return fmt.Errorf("%w: names, selector or namespace is required", ErrInvalidRequest)
+	}
+	if req.Concurrency < 0 || req.Concurrency > MaxConcurrency {
+		return fmt.Errorf("%w: concurrency must be between 1 and %d", ErrInvalidRequest, MaxConcurrency)
+	}
+	return nil
+}
+
+// selectApplications returns the applications a request acts on, ordered
+// by name, along with their results so far. Applications listed by name
+// that do not exist are nil, with a failed result.
+func (r *Runner) selectApplications(ctx context.Context, req Request) ([]*kubernetes.Application, []Result, error) {
+	if len(req.Names) > 0 {
+		names := append([]string(nil), req.Names...)
+		sort.Strings(names)
+		var targets []*kubernetes.Application
+		var results []Result
+		for i, name := range names {
+			if i > 0 && name == names[i-1] {
+				continue
+			}
+			app, err := r.client.GetApplication(ctx, name)
+			if err != nil {
+				targets = append(targets, nil)
+				results = append(results, Result{Name: name, Status: ResultFailed, Message: err.Error()})
+				continue
+			}
+			targets = append(targets, app)
+			results = append(results, Result{})
+		}
+		return targets, results, nil
+	}
+
+	selector, err := labels.Parse(req.Selector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	apps, err := r.client.GetApplications(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var targets []*kubernetes.Application
+	for _, app := range apps {
+		if req.Namespace != "" && app.Namespace != req.Namespace {
+			continue
+		}
+		if selector.Matches(labels.Set(app.Labels)) {
+			targets = append(targets, app)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+	return targets, make([]Result, len(targets)), nil
+}
+
+// dependencyWaves groups the indices of the existing targets by their
+// dependency wave, lowest first
+func (r *Runner) dependencyWaves(targets []*kubernetes.Application) ([][]int, error) {
+	graph, err := r.client.GetDependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+	numbers := make(map[string]int, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		numbers[node.Name] = node.Wave
+	}
+
+	byNumber := make(map[int][]int)
+	for i, app := range targets {
+		if app != nil {
+			byNumber[numbers[app.Name]] = append(byNumber[numbers[app.Name]], i)
+		}
+	}
+	keys := make([]int, 0, len(byNumber))
+	for number := range byNumber {
+		keys = append(keys, number)
+	}
+	sort.Ints(keys)
+	waves := make([][]int, len(keys))
+	for i, number := range keys {
+		waves[i] = byNumber[number]
+	}
+	return waves, nil
+}
+
+// awaitWave refreshes the applications of a wave that were synced until
+// none of them is progressing anymore, or waveTimeout passes
+func (r *Runner) awaitWave(ctx context.Context, targets []*kubernetes.Application, results []Result, wave []int) {
+	ctx, cancel := context.WithTimeout(ctx, waveTimeout)
+	defer cancel()
+	ticker := time.NewTicker(kubernetes.WavePollInterval)
+	defer ticker.Stop()
+
+	for {
+		progressing := false
+		for _, i := range wave {
+			if results[i].Status != ResultSucceeded {
+				continue
+			}
+			app, err := r.client.RefreshApplication(ctx, targets[i].Name)
+			if err == nil && app.Status == kubernetes.ApplicationStatusProgressing {
+				progressing = true
+			}
+		}
+		if !progressing {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			r.logger.WarnContext(ctx, "Sync wave did not finish progressing", "timeout", waveTimeout)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// act performs the action of req on a single application
+func (r *Runner) act(ctx context.Context, req Request, app *kubernetes.Application, actor Actor) Result {
+	result := Result{Name: app.Name, Namespace: app.Namespace}
+	if req.DryRun {
+		result.Status = ResultDryRun
+		result.Message = fmt.Sprintf("would %s, application is %s and %s", req.Action, app.SyncStatus, app.Status)
+		return result
+	}
+
+	var err error
+	switch req.Action {
+	case ActionSync:
+		var synced *kubernetes.Application
+		synced, err = r.scheduler.SyncNow(ctx, app.Name, actor.ID, actor.Name)
+		if errors.Is(err, kubernetes.ErrBlocked) {
+			// Its dependencies failed or are not part of the batch
+			result.Status = ResultSkipped
+			result.Message = err.Error()
+			return result
+		}
+		if err == nil {
+			result.Message = "synced"
+			if synced.SyncedCommit != "" {
+				result.Message += " to " + synced.SyncedCommit
+			}
+		}
+	case ActionRestart:
+		var workloads []kubernetes.WorkloadResult
+		workloads, err = r.client.RolloutApplication(ctx, app.Name, kubernetes.RolloutRequest{Action: kubernetes.RolloutRestart})
+		if errors.Is(err, kubernetes.ErrNoWorkloads) {
+			result.Status = ResultSkipped
+			result.Message = err.Error()
+			return result
+		}
+		result.Message = fmt.Sprintf("restarted %d workloads", len(workloads))
+	case ActionDelete:
+		// Only delete the version that was selected
+		err = r.client.DeleteApplication(ctx, app.Name, app.ResourceVersion)
+		if errors.Is(err, kubernetes.ErrConflict) {
+			err = errors.New("application was modified since it was selected")
+		}
+		result.Message = "deleted"
+	}
+	if err != nil {
+		r.logger.WarnContext(ctx, "Batch action failed", "action", req.Action, "application", app.Name, "error", err)
+		result.Status = ResultFailed
+		result.Message = err.Error()
+		return result
+	}
+	result.Status = ResultSucceeded
+	return result
+}
@@ -0,0 +1,92 @@
+package catalog
+
+// builtinTemplates are the templates every catalog starts with. Admins can
+// change or delete them like any other template.
+var builtinTemplates = []Template{
+	{
+		Name:        "web-service",
+		Description: "A Deployment serving HTTP behind a ClusterIP Service",
+		Parameters: []Parameter{
+			{Name: "image", Description: "Container image to run", Required: true},
+			{Name: "replicas", Description: "Number of pods", Type: ParameterTypeInteger, Default: "1", Pattern: `[0-9]+`},
+			{Name: "port", Description: "Port the container listens on", Type: ParameterTypeInteger, Default: "8080"},
+			{Name: "servicePort", Description: "Port the Service exposes", Type: ParameterTypeInteger, Default: "80"},
+		},
+		Manifests: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Name }}
+  labels:
+    app.kubernetes.io/name: {{ .Name }}
+spec:
+  replicas: {{ .Params.replicas }}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{ .Name }}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{ .Name }}
+    spec:
+      containers:
+        - name: {{ .Name }}
+          image: {{ .Params.image | quote }}
+          ports:
+            - name: http
+              containerPort: {{ .Params.port }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Name }}
+  labels:
+    app.kubernetes.io/name: {{ .Name }}
+spec:
+  selector:
+    app.kubernetes.io/name: {{ .Name }}
+  ports:
+    - name: http
+      port: {{ .Params.servicePort }}
+      targetPort: http
+`,
+	},
+	{
+		Name:        "cron-worker",
+		Description: "A CronJob running a container on a schedule",
+		Parameters: []Parameter{
+			{Name: "image", Description: "Container image to run", Required: true},
+			{Name: "schedule", Description: "Cron schedule of the job", Default: "*/15 * * * *"},
+			{Name: "command", Description: "Command to run instead of the image entrypoint, split on spaces"},
+			{Name: "concurrencyPolicy", Description: "What to do when the previous run is still active", Default: "Forbid", Enum: []string{"Allow", "Forbid", "Replace"}},
+		},
+		Manifests: `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: {{ .Name }}
+  labels:
+    app.kubernetes.io/name: {{ .Name }}
+spec:
+  schedule: {{ .Params.schedule | quote }}
+  concurrencyPolicy: {{ .Params.concurrencyPolicy }}
+  jobTemplate:
+    spec:
+      template:
+        metadata:
+          labels:
+            app.kubernetes.io/name: {{ .Name }}
+        spec:
+          restartPolicy: OnFailure
+          containers:
+            - name: {{ .Name }}
+              image: {{ .Params.image | quote }}
+              {{- with .Params.command }}
+              command:
+                {{- range splitList " " . }}
+                {{- if . }}
+                - {{ . | quote }}
+                {{- end }}
+                {{- end }}
+              {{- end }}
+`,
+	},
+}
@@ -0,0 +1,195 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+var (
+	// ErrTemplateNotFound is returned when a template does not exist
+	ErrTemplateNotFound = errors.New("template not found")
+	// ErrTemplateExists is returned when creating a template whose name is already taken
+	ErrTemplateExists = errors.New("template already exists")
+	// ErrInvalidTemplate is returned for templates that cannot be stored
+	ErrInvalidTemplate = errors.New("invalid template")
+)
+
+// templateName matches the names of templates, which are DNS labels
+var templateName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// Template is an admin-defined blueprint of an application. Its manifests
+// are a Go text/template, with the Sprig functions, that is executed with
+// the application name as .Name, its namespace as .Namespace and the
+// validated parameters as .Params.
+type Template struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Parameters  []Parameter `json:"parameters"`
+	Manifests   string      `json:"manifests"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+// entry is a stored template with its parsed manifests
+type entry struct {
+	template Template
+	parsed   *template.Template
+}
+
+// Catalog keeps the templates applications can be created from
+type Catalog struct {
+	mu        sync.RWMutex
+	templates map[string]*entry
+	now       func() time.Time
+}
+
+// New creates a catalog holding the built-in templates
+func New() *Catalog {
+	c := &Catalog{
+		templates: make(map[string]*entry),
+		now:       time.Now,
+	}
+	for _, t := range builtinTemplates {
+		if _, err := c.Create(t); err != nil {
+			panic(fmt.Sprintf("invalid built-in template %s: %v", t.Name, err))
+		}
+	}
+	return c
+}
+
+// List returns all templates ordered by name
+func (c *Catalog) List() []Template {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]Template, 0, len(c.templates))
+	for _, e := range c.templates {
+		result = append(result, e.template.clone())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Get returns a single template
+func (c *Catalog) Get(name string) (Template, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.templates[name]
+	if !ok {
+		return Template{}, ErrTemplateNotFound
+	}
+	return e.template.clone(), nil
+}
+
+// Create adds a template to the catalog and returns it as stored
+func (c *Catalog) Create(t Template) (Template, error) {
+	parsed, err := parse(&t)
+	if err != nil {
+		return Template{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.templates[t.Name]; ok {
+		return Template{}, ErrTemplateExists
+	}
+	t.CreatedAt = c.now()
+	t.UpdatedAt = t.CreatedAt
+	c.templates[t.Name] = &entry{template: t.clone(), parsed: parsed}
+	return t.clone(), nil
+}
+
+// Update replaces the template called name and returns it as stored.
+// Applications created from it render the new manifests from their next
+// refresh on.
+func (c *Catalog) Update(name string, t Template) (Template, error) {
+	t.Name = name
+	parsed, err := parse(&t)
+	if err != nil {
+		return Template{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing, ok := c.templates[name]
+	if !ok {
+		return Template{}, ErrTemplateNotFound
+	}
+	t.CreatedAt = existing.template.CreatedAt
+	t.UpdatedAt = c.now()
+	c.templates[name] = &entry{template: t.clone(), parsed: parsed}
+	return t.clone(), nil
+}
+
+// Delete removes a template from the catalog. Applications created from it
+// can no longer be rendered.
+func (c *Catalog) Delete(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.templates[name]; !ok {
+		return ErrTemplateNotFound
+	}
+	delete(c.templates, name)
+	return nil
+}
+
+// Len returns the number of templates in the catalog
+func (c *Catalog) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.templates)
+}
+
+// parse validates a template and parses its manifests
+func parse(t *Template) (*template.Template, error) {
+	var problems []string
+	if !templateName.MatchString(t.Name) {
+		problems = append(problems, "name must be a DNS label")
+	}
+	if strings.TrimSpace(t.Manifests) == "" {
+		problems = append(problems, "manifests must not be empty")
+	}
+	seen := make(map[string]bool, len(t.Parameters))
+	for i := range t.Parameters {
+		param := &t.Parameters[i]
+		if seen[param.Name] {
+			problems = append(problems, fmt.Sprintf("parameter %s is defined twice", param.Name))
+		}
+		seen[param.Name] = true
+		if err := param.check(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	parsed, err := template.New(t.Name).Funcs(sprig.TxtFuncMap()).Option("missingkey=error").Parse(t.Manifests)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, strings.Join(problems, "; "))
+	}
+	return parsed, nil
+}
+
+// clone returns a copy of a template that shares no slices with it
+func (t Template) clone() Template {
+	params := make([]Parameter, len(t.Parameters))
+	for i, param := range t.Parameters {
+		param.Enum = append([]string(nil), param.Enum...)
+		params[i] = param
+	}
+	t.Parameters = params
+	return t
+}
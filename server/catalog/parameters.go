@@ -0,0 +1,162 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParameterType is the type of the value of a template parameter
+type ParameterType string
+
+const (
+	// ParameterTypeString accepts any value
+	ParameterTypeString ParameterType = "string"
+	// ParameterTypeInteger accepts whole numbers
+	ParameterTypeInteger ParameterType = "integer"
+	// ParameterTypeBoolean accepts true and false
+	ParameterTypeBoolean ParameterType = "boolean"
+)
+
+// ErrInvalidParameters is returned when the parameters given for a template
+// don't match its parameter schema
+var ErrInvalidParameters = errors.New("invalid parameters")
+
+// parameterName matches the names of parameters, which are used as
+// .Params.<name> in the manifests
+var parameterName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Parameter describes a value developers provide when creating an
+// application from a template
+type Parameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Type is string if empty
+	Type     ParameterType `json:"type,omitempty"`
+	Required bool          `json:"required,omitempty"`
+	// Default is used when no value is given
+	Default string `json:"default,omitempty"`
+	// Enum lists the allowed values, if set
+	Enum []string `json:"enum,omitempty"`
+	// Pattern is a regular expression the whole value must match, if set
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// check reports problems with the definition of a parameter
+func (p *Parameter) check() error {
+	if !parameterName.MatchString(p.Name) {
+		return fmt.Errorf("parameter name %q must be letters, digits and underscores", p.Name)
+	}
+	switch p.Type {
+	case "", ParameterTypeString, ParameterTypeInteger, ParameterTypeBoolean:
+	default:
+		return fmt.Errorf("parameter %s has unknown type %q", p.Name, p.Type)
+	}
+	if p.Pattern != "" {
+		if _, err := regexp.Compile(p.Pattern); err != nil {
+			return fmt.Errorf("parameter %s has an invalid pattern: %v", p.Name, err)
+		}
+	}
+	for _, value := range p.Enum {
+		if _, err := p.convert(value); err != nil {
+			return fmt.Errorf("parameter %s has an invalid enum value: %v", p.Name, err)
+		}
+	}
+	if p.Default != "" {
+		if _, err := p.convert(p.Default); err != nil {
+			return fmt.Errorf("parameter %s has an invalid default: %v", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// convert validates a value of the parameter and converts it to its type
+func (p *Parameter) convert(value string) (interface{}, error) {
+	if len(p.Enum) > 0 && !slices.Contains(p.Enum, value) {
+		return nil, fmt.Errorf("%s must be one of %s", p.Name, strings.Join(p.Enum, ", "))
+	}
+	if p.Pattern != "" {
+		// Patterns were compiled when the template was stored
+		if !regexp.MustCompile(`^(?:` + p.Pattern + `)$`).MatchString(value) {
+			return nil, fmt.Errorf("%s must match %s", p.Name, p.Pattern)
+		}
+	}
+
+	switch p.Type {
+	case ParameterTypeInteger:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", p.Name)
+		}
+		return n, nil
+	case ParameterTypeBoolean:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", p.Name)
+		}
+		return b, nil
+	}
+	return value, nil
+}
+
+// Validate checks values against the parameter schema of a template and
+// returns them converted to their types, with defaults filled in
+func (t *Template) Validate(values map[string]string) (map[string]interface{}, error) {
+	var problems []string
+	params := make(map[string]interface{}, len(t.Parameters))
+	known := make(map[string]bool, len(t.Parameters))
+	for i := range t.Parameters {
+		param := &t.Parameters[i]
+		known[param.Name] = true
+
+		value, ok := values[param.Name]
+		if !ok {
+			if param.Required && param.Default == "" {
+				problems = append(problems, param.Name+" is required")
+				continue
+			}
+			value = param.Default
+		}
+		converted, err := param.convert(value)
+		if err != nil && (ok || value != "") {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if err != nil {
+			// An optional parameter without a default is empty
+			converted = zeroValue(param.Type)
+		}
+		params[param.Name] = converted
+	}
+
+	var unknown []string
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, "unknown parameter "+name)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidParameters, strings.Join(problems, "; "))
+	}
+	return params, nil
+}
+
+// zeroValue returns the value of an optional parameter that is not set
+func zeroValue(t ParameterType) interface{} {
+	switch t {
+	case ParameterTypeInteger:
+		return int64(0)
+	case ParameterTypeBoolean:
+		return false
+	}
+	return ""
+}
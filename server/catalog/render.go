@@ -0,0 +1,44 @@
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// renderData is what the manifests of a template are executed with
+type renderData struct {
+	Name      string
+	Namespace string
+	Params    map[string]interface{}
+}
+
+// Render validates the template parameters of app and executes the
+// manifests of its template. It returns a stream of YAML documents.
+func (c *Catalog) Render(app *kubernetes.Application) ([]byte, error) {
+	if app.Source == nil || app.Source.Template == nil {
+		return nil, errors.New("application has no template source")
+	}
+	source := app.Source.Template
+
+	c.mu.RLock()
+	e, ok := c.templates[source.Name]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, source.Name)
+	}
+
+	params, err := e.template.Validate(source.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	data := renderData{Name: app.Name, Namespace: app.Namespace, Params: params}
+	if err := e.parsed.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", source.Name, err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,137 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ReloadInterval is how often the certificate files are checked for changes
+const ReloadInterval = 30 * time.Second
+
+// Options configures the TLS settings of the server listeners
+type Options struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables client certificate verification against these CAs
+	ClientCAFile string
+	// RequireClientCert rejects connections without a valid client certificate
+	RequireClientCert bool
+}
+
+// Reloader serves TLS configurations built from certificate files and
+// reloads them when the files change, e.g. when cert-manager rotates a
+// Secret mounted into the pod
+type Reloader struct {
+	opts   Options
+	logger *slog.Logger
+
+	mu      sync.RWMutex
+	config  *tls.Config
+	content []byte
+}
+
+// NewReloader loads the certificate files and returns a reloader serving them
+func NewReloader(opts Options, logger *slog.Logger) (*Reloader, error) {
+	r := &Reloader{opts: opts, logger: logger}
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig returns a server TLS configuration that always uses the most
+// recently loaded certificates
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.config, nil
+		},
+	}
+}
+
+// Run checks the certificate files for changes every interval until ctx is
+// done. Failed reloads are logged and the previous certificates kept.
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.reload()
+			if err != nil {
+				r.logger.Error("Failed to reload TLS certificates", "error", err)
+			} else if changed {
+				r.logger.Info("Reloaded TLS certificates", "certFile", r.opts.CertFile)
+			}
+		}
+	}
+}
+
+// reload reads the certificate files and rebuilds the configuration if
+// their content changed
+func (r *Reloader) reload() (bool, error) {
+	certPEM, err := os.ReadFile(r.opts.CertFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(r.opts.KeyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read private key: %w", err)
+	}
+	var caPEM []byte
+	if r.opts.ClientCAFile != "" {
+		if caPEM, err = os.ReadFile(r.opts.ClientCAFile); err != nil {
+			return false, fmt.Errorf("failed to read client CA: %w", err)
+		}
+	}
+
+	content := bytes.Join([][]byte{certPEM, keyPEM, caPEM}, nil)
+	r.mu.RLock()
+	unchanged := bytes.Equal(content, r.content)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("failed to load key pair: %w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		// Both HTTP/2 (for gRPC) and HTTP/1.1 are served
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if caPEM != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return false, errors.New("client CA file contains no certificates")
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.opts.RequireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	r.mu.Lock()
+	r.config = config
+	r.content = content
+	r.mu.Unlock()
+	return true, nil
+}
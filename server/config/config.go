@@ -0,0 +1,334 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/sysintelligent/devops-bridge/server/autosync"
+	"github.com/sysintelligent/devops-bridge/server/logging"
+	"github.com/sysintelligent/devops-bridge/server/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+// Auth modes
+const (
+	// AuthModeToken authenticates callers with bearer tokens
+	AuthModeToken = "token"
+	// AuthModeNone treats every caller as an admin, for local development only
+	AuthModeNone = "none"
+)
+
+// Config is the configuration of the bridge server
+type Config struct {
+	HTTP            ListenerConfig       `mapstructure:"http" yaml:"http"`
+	GRPC            ListenerConfig       `mapstructure:"grpc" yaml:"grpc"`
+	TLS             TLSConfig            `mapstructure:"tls" yaml:"tls"`
+	CORS            CORSConfig           `mapstructure:"cors" yaml:"cors"`
+	Auth            AuthConfig           `mapstructure:"auth" yaml:"auth"`
+	Kubernetes      KubernetesConfig     `mapstructure:"kubernetes" yaml:"kubernetes"`
+	Log             LogConfig            `mapstructure:"log" yaml:"log"`
+	Metrics         MetricsConfig        `mapstructure:"metrics" yaml:"metrics"`
+	Tracing         TracingConfig        `mapstructure:"tracing" yaml:"tracing"`
+	Audit           AuditConfig          `mapstructure:"audit" yaml:"audit"`
+	LeaderElection  LeaderElectionConfig `mapstructure:"leaderElection" yaml:"leaderElection"`
+	Sync            SyncConfig           `mapstructure:"sync" yaml:"sync"`
+	Sources         SourcesConfig        `mapstructure:"sources" yaml:"sources"`
+	ShutdownTimeout time.Duration        `mapstructure:"shutdownTimeout" yaml:"shutdownTimeout"`
+}
+
+// SinglePort reports whether REST, gRPC and gRPC-Web are all served on the
+// HTTP listener, which is the case when both listeners share an address
+func (c *Config) SinglePort() bool {
+	return c.HTTP.Address == c.GRPC.Address
+}
+
+// ListenerConfig configures a listening socket
+type ListenerConfig struct {
+	// Address is host:port, e.g. :8080
+	Address string `mapstructure:"address" yaml:"address"`
+}
+
+// TLSConfig configures TLS on both listeners. TLS is disabled when no
+// certificate is set.
+type TLSConfig struct {
+	CertFile string `mapstructure:"certFile" yaml:"certFile"`
+	KeyFile  string `mapstructure:"keyFile" yaml:"keyFile"`
+	// ClientCAFile enables client certificate authentication against these CAs
+	ClientCAFile string `mapstructure:"clientCAFile" yaml:"clientCAFile"`
+	// RequireClientCert rejects callers without a valid client certificate
+	RequireClientCert bool `mapstructure:"requireClientCert" yaml:"requireClientCert"`
+}
+
+// Enabled reports whether TLS is configured
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// CORSConfig configures which browser origins may call the APIs
+type CORSConfig struct {
+	// AllowedOrigins are origins such as https://ui.example.com, or * for any
+	AllowedOrigins []string `mapstructure:"allowedOrigins" yaml:"allowedOrigins"`
+}
+
+// AuthConfig configures authentication
+type AuthConfig struct {
+	// Mode is token or none
+	Mode       string `mapstructure:"mode" yaml:"mode"`
+	UserToken  string `mapstructure:"userToken" yaml:"userToken"`
+	AdminToken string `mapstructure:"adminToken" yaml:"adminToken"`
+}
+
+// KubernetesConfig configures access to the cluster
+type KubernetesConfig struct {
+	// Kubeconfig is the path of the kubeconfig file. If empty, the in-cluster
+	// configuration is used when available, and ~/.kube/config otherwise.
+	Kubeconfig string `mapstructure:"kubeconfig" yaml:"kubeconfig"`
+	// Context is the kubeconfig context to use instead of the current one
+	Context string `mapstructure:"context" yaml:"context"`
+}
+
+// LogConfig configures logging
+type LogConfig struct {
+	// Level is debug, info, warn or error
+	Level string `mapstructure:"level" yaml:"level"`
+	// Format is text or json
+	Format string `mapstructure:"format" yaml:"format"`
+}
+
+// MetricsConfig configures the Prometheus endpoint on the HTTP listener
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled" yaml:"enabled"`
+	Path    string `mapstructure:"path" yaml:"path"`
+}
+
+// TracingConfig configures trace export
+type TracingConfig struct {
+	// Exporter is otlp, stdout or none
+	Exporter string `mapstructure:"exporter" yaml:"exporter"`
+}
+
+// AuditConfig configures the audit log sinks
+type AuditConfig struct {
+	// LogFile is a path to append entries to, or - for stdout
+	LogFile    string `mapstructure:"logFile" yaml:"logFile"`
+	WebhookURL string `mapstructure:"webhookUrl" yaml:"webhookUrl"`
+}
+
+// LeaderElectionConfig configures the election of the replica that runs
+// the background controllers
+type LeaderElectionConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Namespace of the Lease, by default the namespace the server runs in
+	Namespace string `mapstructure:"namespace" yaml:"namespace"`
+	LeaseName string `mapstructure:"leaseName" yaml:"leaseName"`
+	// Identity of this replica, by default the host name
+	Identity string `mapstructure:"identity" yaml:"identity"`
+}
+
+// SyncConfig configures the auto-sync scheduler
+type SyncConfig struct {
+	// Interval is the time between refreshes of an application
+	Interval time.Duration `mapstructure:"interval" yaml:"interval"`
+	// Concurrency is the number of applications refreshed at once
+	Concurrency int `mapstructure:"concurrency" yaml:"concurrency"`
+}
+
+// SourcesConfig configures where application manifests are read from
+type SourcesConfig struct {
+	// CacheDir holds the local mirrors of Git repositories
+	CacheDir string `mapstructure:"cacheDir" yaml:"cacheDir"`
+}
+
+// setting describes a single configuration key
+type setting struct {
+	key   string
+	flag  string
+	env   string
+	value interface{}
+	usage string
+}
+
+// settings lists every configuration key with its flag, environment
+// variable and default value
+var settings = []setting{
+	{"http.address", "http-address", "HTTP_ADDRESS", ":8080", "address the HTTP server listens on"},
+	{"grpc.address", "grpc-address", "GRPC_ADDRESS", ":9090", "address the gRPC server listens on, the HTTP address to serve both on one port"},
+	{"tls.certFile", "tls-cert-file", "TLS_CERT_FILE", "", "TLS certificate file, enables TLS on both servers"},
+	{"tls.keyFile", "tls-key-file", "TLS_KEY_FILE", "", "TLS private key file"},
+	{"tls.clientCAFile", "tls-client-ca-file", "TLS_CLIENT_CA_FILE", "", "CA file to verify client certificates with"},
+	{"tls.requireClientCert", "tls-require-client-cert", "TLS_REQUIRE_CLIENT_CERT", false, "reject callers without a valid client certificate"},
+	{"cors.allowedOrigins", "cors-allowed-origins", "CORS_ALLOWED_ORIGINS", []string{}, "comma-separated origins of browser pages allowed to call the APIs, * for any"},
+	{"auth.mode", "auth-mode", "AUTH_MODE", AuthModeToken, "authentication mode: token or none"},
+	{"auth.userToken", "auth-user-token", "DEMO_USER_TOKEN", "demo-token", "bearer token of the demo user"},
+	{"auth.adminToken", "auth-admin-token", "DEMO_ADMIN_TOKEN", "admin-token", "bearer token of the demo admin"},
+	{"kubernetes.kubeconfig", "kubeconfig", "KUBECONFIG", "", "kubeconfig file (default in-cluster, then ~/.kube/config)"},
+	{"kubernetes.context", "kube-context", "KUBE_CONTEXT", "", "kubeconfig context to use"},
+	{"log.level", "log-level", "LOG_LEVEL", "info", "log level: debug, info, warn or error"},
+	{"log.format", "log-format", "LOG_FORMAT", logging.FormatText, "log format: text or json"},
+	{"metrics.enabled", "metrics-enabled", "METRICS_ENABLED", true, "serve Prometheus metrics"},
+	{"metrics.path", "metrics-path", "METRICS_PATH", "/metrics", "path of the Prometheus metrics endpoint"},
+	{"tracing.exporter", "tracing-exporter", "OTEL_TRACES_EXPORTER", tracing.ExporterNone, "trace exporter: otlp, stdout or none"},
+	{"audit.logFile", "audit-log-file", "AUDIT_LOG_FILE", "", "file to append audit entries to, - for stdout"},
+	{"audit.webhookUrl", "audit-webhook-url", "AUDIT_WEBHOOK_URL", "", "URL to post audit entries to"},
+	{"leaderElection.enabled", "leader-election", "LEADER_ELECTION_ENABLED", false, "elect a leader to run the background controllers, for multiple replicas"},
+	{"leaderElection.namespace", "leader-election-namespace", "POD_NAMESPACE", "", "namespace of the leader election Lease (default the server's namespace)"},
+	{"leaderElection.leaseName", "leader-election-lease-name", "LEADER_ELECTION_LEASE_NAME", "devops-bridge", "name of the leader election Lease"},
+	{"leaderElection.identity", "leader-election-identity", "POD_NAME", "", "identity of this replica in the leader election (default the host name)"},
+	{"sync.interval", "sync-interval", "SYNC_INTERVAL", autosync.DefaultInterval, "time between refreshes of the sync status of an application"},
+	{"sync.concurrency", "sync-concurrency", "SYNC_CONCURRENCY", autosync.DefaultConcurrency, "number of applications refreshed at once"},
+	{"sources.cacheDir", "sources-cache-dir", "SOURCES_CACHE_DIR", filepath.Join(os.TempDir(), "devops-bridge", "repos"), "directory of the local Git repository cache"},
+	{"shutdownTimeout", "shutdown-timeout", "SHUTDOWN_TIMEOUT", 5 * time.Second, "time to wait for requests to finish on shutdown"},
+}
+
+// Load builds the configuration from command line arguments, environment
+// variables and the YAML file given by --config or CONFIG_FILE, in that
+// order of precedence, on top of the defaults. printConfig reports whether
+// --print-config was given. The configuration is not validated.
+func Load(args []string) (cfg *Config, printConfig bool, err error) {
+	v := viper.New()
+	flags := pflag.NewFlagSet("devops-bridge-server", pflag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file")
+	flags.BoolVar(&printConfig, "print-config", false, "print the effective configuration and exit")
+
+	for _, s := range settings {
+		v.SetDefault(s.key, s.value)
+		switch value := s.value.(type) {
+		case string:
+			flags.String(s.flag, value, s.usage)
+		case bool:
+			flags.Bool(s.flag, value, s.usage)
+		case int:
+			flags.Int(s.flag, value, s.usage)
+		case time.Duration:
+			flags.Duration(s.flag, value, s.usage)
+		case []string:
+			flags.StringSlice(s.flag, value, s.usage)
+		}
+		v.BindEnv(s.key, s.env)
+		// Only flags given on the command line override the other sources
+		v.BindPFlag(s.key, flags.Lookup(s.flag))
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, false, err
+	}
+
+	if *configFile != "" {
+		v.SetConfigFile(*configFile)
+		v.SetConfigType("yaml")
+		if err := v.ReadInConfig(); err != nil {
+			return nil, false, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	cfg = &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, false, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return cfg, printConfig, nil
+}
+
+// Validate checks the configuration and returns all problems found
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	for _, address := range []struct{ name, value string }{
+		{"http.address", c.HTTP.Address},
+		{"grpc.address", c.GRPC.Address},
+	} {
+		_, _, err := net.SplitHostPort(address.value)
+		check(err == nil, "%s %q must be host:port", address.name, address.value)
+	}
+
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "tls.certFile and tls.keyFile must be set together")
+	check(c.TLS.ClientCAFile == "" || c.TLS.Enabled(), "tls.clientCAFile requires tls.certFile")
+	check(!c.TLS.RequireClientCert || c.TLS.ClientCAFile != "", "tls.requireClientCert requires tls.clientCAFile")
+	for _, file := range []struct{ name, path string }{
+		{"tls.certFile", c.TLS.CertFile},
+		{"tls.keyFile", c.TLS.KeyFile},
+		{"tls.clientCAFile", c.TLS.ClientCAFile},
+	} {
+		if file.path != "" {
+			_, err := os.Stat(file.path)
+			check(err == nil, "%s: %v", file.name, err)
+		}
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		u, err := url.Parse(origin)
+		check(origin == "*" || err == nil && u.Scheme != "" && u.Host != "" && u.Path == "" && u.RawQuery == "",
+			"cors.allowedOrigins %q must be * or scheme://host[:port]", origin)
+	}
+
+	switch c.Auth.Mode {
+	case AuthModeToken:
+		check(c.Auth.UserToken != "" && c.Auth.AdminToken != "", "auth.userToken and auth.adminToken are required in token mode")
+		check(c.Auth.UserToken != c.Auth.AdminToken, "auth.userToken and auth.adminToken must differ")
+	case AuthModeNone:
+	default:
+		check(false, "auth.mode %q must be %s or %s", c.Auth.Mode, AuthModeToken, AuthModeNone)
+	}
+
+	if c.Kubernetes.Kubeconfig != "" {
+		_, err := os.Stat(c.Kubernetes.Kubeconfig)
+		check(err == nil, "kubernetes.kubeconfig: %v", err)
+	}
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level %q must be debug, info, warn or error", c.Log.Level)
+	check(c.Log.Format == logging.FormatText || c.Log.Format == logging.FormatJSON, "log.format %q must be text or json", c.Log.Format)
+
+	check(strings.HasPrefix(c.Metrics.Path, "/") && !strings.HasPrefix(c.Metrics.Path, "/api/"),
+		"metrics.path %q must start with / and not be under /api/", c.Metrics.Path)
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterOTLP, tracing.ExporterStdout, tracing.ExporterNone:
+	default:
+		check(false, "tracing.exporter %q must be otlp, stdout or none", c.Tracing.Exporter)
+	}
+
+	check(!c.LeaderElection.Enabled || c.LeaderElection.LeaseName != "", "leaderElection.leaseName is required when leader election is enabled")
+
+	check(c.Sync.Interval > 0, "sync.interval must be positive")
+	check(c.Sync.Concurrency > 0, "sync.concurrency must be positive")
+	check(c.Sources.CacheDir != "", "sources.cacheDir is required")
+
+	check(c.ShutdownTimeout > 0, "shutdownTimeout must be positive")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Print writes the configuration as YAML to w, with tokens redacted
+func (c *Config) Print(w io.Writer) error {
+	redacted := *c
+	for _, token := range []*string{&redacted.Auth.UserToken, &redacted.Auth.AdminToken} {
+		if *token != "" {
+			*token = "REDACTED"
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(redacted); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,64 @@
+package grpcweb
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// corsAllowedHeaders are the request headers browsers may send to the
+// gRPC-Web and REST APIs from another origin
+var corsAllowedHeaders = strings.Join([]string{
+	"Authorization", "Content-Type", "If-Match", "X-Request-ID", "Traceparent", "Tracestate",
+	"X-Grpc-Web", "X-User-Agent", "Grpc-Timeout",
+}, ", ")
+
+// corsExposedHeaders are the response headers scripts of another origin may
+// read, including the gRPC status sent in headers by trailers-only responses
+var corsExposedHeaders = strings.Join([]string{
+	"ETag", "X-Request-ID", "Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin",
+}, ", ")
+
+// CORS lets browser pages served from allowedOrigins, such as a UI on
+// another host, call the gRPC-Web and REST APIs served by next. It answers
+// preflight requests itself. An origin of "*" allows any origin; without
+// origins, next is returned as is and only same-origin pages can call it.
+func CORS(allowedOrigins []string, next http.Handler) http.Handler {
+	if len(allowedOrigins) == 0 {
+		return next
+	}
+	allowAny := slices.Contains(allowedOrigins, "*")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		allowed := allowAny || slices.Contains(allowedOrigins, origin)
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !allowed {
+				// Without the allow headers the browser blocks the request
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE")
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,176 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// Content types of gRPC-Web requests. The text variant base64-encodes
+// request and response bodies.
+const (
+	contentTypeWeb     = "application/grpc-web"
+	contentTypeWebText = "application/grpc-web-text"
+)
+
+// trailerFlag marks the frame carrying the trailers at the end of a
+// gRPC-Web response body
+const trailerFlag = 0x80
+
+// IsGRPCWebRequest reports whether r is a gRPC-Web request
+func IsGRPCWebRequest(r *http.Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), contentTypeWeb)
+}
+
+// IsGRPCRequest reports whether r is a native gRPC request
+func IsGRPCRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") &&
+		!IsGRPCWebRequest(r)
+}
+
+// Handler translates gRPC-Web requests into gRPC requests served by
+// grpcHandler, usually a *grpc.Server, so browsers can call the gRPC API
+// without a proxy such as Envoy. The gRPC trailers are sent in the response
+// body as the protocol requires.
+func Handler(grpcHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsGRPCWebRequest(r) {
+			http.Error(w, "not a gRPC-Web request", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		contentType := r.Header.Get("Content-Type")
+		text := strings.HasPrefix(contentType, contentTypeWebText)
+
+		// Present the request as the gRPC server expects it
+		req := r.Clone(r.Context())
+		req.ProtoMajor, req.ProtoMinor, req.Proto = 2, 0, "HTTP/2.0"
+		req.Header.Set("Content-Type", grpcContentType(contentType))
+		req.Header.Set("Te", "trailers")
+		req.Header.Del("Content-Length")
+		req.ContentLength = -1
+		if text {
+			req.Body = io.NopCloser(base64.NewDecoder(base64.StdEncoding, r.Body))
+		}
+
+		rw := &responseWriter{w: w, header: http.Header{}, contentType: contentType, text: text}
+		grpcHandler.ServeHTTP(rw, req)
+		rw.finish()
+	})
+}
+
+// grpcContentType maps a gRPC-Web content type to its gRPC counterpart,
+// keeping the message encoding, e.g. application/grpc-web+proto becomes
+// application/grpc+proto
+func grpcContentType(contentType string) string {
+	for _, prefix := range []string{contentTypeWebText, contentTypeWeb} {
+		if strings.HasPrefix(contentType, prefix) {
+			return "application/grpc" + strings.TrimPrefix(contentType, prefix)
+		}
+	}
+	return contentType
+}
+
+// responseWriter turns a gRPC response into a gRPC-Web response
+type responseWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	contentType string
+	text        bool
+
+	wroteHeader bool
+	// trailers are the trailers declared before the headers were written
+	trailers []string
+	// encoder base64-encodes the body in text mode
+	encoder io.WriteCloser
+}
+
+// Header implements http.ResponseWriter
+func (rw *responseWriter) Header() http.Header {
+	return rw.header
+}
+
+// WriteHeader implements http.ResponseWriter
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+
+	h := rw.w.Header()
+	for key, values := range rw.header {
+		if key == "Trailer" {
+			rw.trailers = append(rw.trailers, values...)
+			continue
+		}
+		h[key] = values
+	}
+	h.Set("Content-Type", rw.contentType)
+	h.Del("Content-Length")
+	rw.w.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.WriteHeader(http.StatusOK)
+	if !rw.text {
+		return rw.w.Write(b)
+	}
+	if rw.encoder == nil {
+		rw.encoder = base64.NewEncoder(base64.StdEncoding, rw.w)
+	}
+	return rw.encoder.Write(b)
+}
+
+// Flush implements http.Flusher, which the gRPC server requires
+func (rw *responseWriter) Flush() {
+	rw.WriteHeader(http.StatusOK)
+	// Each flushed chunk is padded so the client can decode it on its own
+	if rw.encoder != nil {
+		rw.encoder.Close()
+		rw.encoder = nil
+	}
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes the trailers set by the gRPC server as the last frame of
+// the body
+func (rw *responseWriter) finish() {
+	trailers := http.Header{}
+	for _, key := range rw.trailers {
+		for _, key := range strings.Split(key, ",") {
+			key = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(key))
+			if values, ok := rw.header[key]; ok {
+				trailers[key] = values
+			}
+		}
+	}
+	// Trailers that were not declared use the http.TrailerPrefix convention
+	for key, values := range rw.header {
+		if strings.HasPrefix(key, http.TrailerPrefix) {
+			trailers[strings.TrimPrefix(key, http.TrailerPrefix)] = values
+		}
+	}
+
+	var block bytes.Buffer
+	for key, values := range trailers {
+		for _, value := range values {
+			fmt.Fprintf(&block, "%s: %s\r\n", strings.ToLower(key), value)
+		}
+	}
+
+	frame := make([]byte, 5, 5+block.Len())
+	frame[0] = trailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(block.Len()))
+	frame = append(frame, block.Bytes()...)
+
+	rw.Write(frame)
+	rw.Flush()
+}
@@ -0,0 +1,151 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds the time a single readiness check may take
+const DefaultTimeout = 5 * time.Second
+
+// Check reports whether a dependency of the server is usable
+type Check func(ctx context.Context) error
+
+// namedCheck is a registered check
+type namedCheck struct {
+	name string
+	// check is nil for entries that only report a status
+	check Check
+	// status, if set, describes the state behind the check
+	status func() string
+}
+
+// result is the outcome of a check
+type result struct {
+	name   string
+	err    error
+	status string
+}
+
+// Checker serves the liveness and readiness endpoints
+type Checker struct {
+	timeout time.Duration
+
+	mu       sync.RWMutex
+	checks   []namedCheck
+	liveness []namedCheck
+}
+
+// NewChecker creates a checker that gives each readiness check timeout to
+// complete
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// AddReadinessCheck registers a check that must pass for the server to be
+// ready to serve requests
+func (c *Checker) AddReadinessCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// AddReadinessStatus registers an entry of the readiness endpoint that
+// never fails, and shows the result of status with ?verbose
+func (c *Checker) AddReadinessStatus(name string, status func() string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, status: status})
+}
+
+// AddLivenessCheck registers a check that fails liveness, getting the
+// server restarted. It is meant for states the process cannot recover from,
+// never for unreachable dependencies.
+func (c *Checker) AddLivenessCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.liveness = append(c.liveness, namedCheck{name: name, check: check})
+}
+
+// LivezHandler reports that the process is running and serving requests,
+// and runs the liveness checks. It checks no dependencies, so an
+// unreachable dependency never gets the server restarted.
+func (c *Checker) LivezHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.RLock()
+		checks := append([]namedCheck(nil), c.liveness...)
+		c.mu.RUnlock()
+		c.write(w, r, "livez", append([]result{{name: "ping"}}, c.run(r.Context(), checks)...))
+	})
+}
+
+// ReadyzHandler runs the readiness checks concurrently and responds with
+// 503 Service Unavailable if any fails
+func (c *Checker) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.RLock()
+		checks := append([]namedCheck(nil), c.checks...)
+		c.mu.RUnlock()
+		c.write(w, r, "readyz", c.run(r.Context(), checks))
+	})
+}
+
+// run runs checks concurrently and returns their results in registration
+// order
+func (c *Checker) run(ctx context.Context, checks []namedCheck) []result {
+
+	results := make([]result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			results[i] = result{name: check.name}
+			if check.check != nil {
+				results[i].err = check.check(ctx)
+			}
+			if check.status != nil {
+				results[i].status = check.status()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// write responds in the format of the Kubernetes API server's health
+// endpoints: ok, or the failed checks, or every check with ?verbose
+func (c *Checker) write(w http.ResponseWriter, r *http.Request, endpoint string, results []result) {
+	_, verbose := r.URL.Query()["verbose"]
+
+	var out strings.Builder
+	failed := false
+	for _, res := range results {
+		if res.err != nil {
+			failed = true
+			fmt.Fprintf(&out, "[-]%s failed: %v\n", res.name, res.err)
+		} else if verbose && res.status != "" {
+			fmt.Fprintf(&out, "[+]%s ok: %s\n", res.name, res.status)
+		} else if verbose {
+			fmt.Fprintf(&out, "[+]%s ok\n", res.name)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if failed {
+		fmt.Fprintf(&out, "%s check failed\n", endpoint)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else if verbose {
+		fmt.Fprintf(&out, "%s check passed\n", endpoint)
+	} else {
+		out.WriteString("ok")
+	}
+	fmt.Fprint(w, out.String())
+}
@@ -0,0 +1,259 @@
+package incidents
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// State represents the lifecycle state of an incident
+type State string
+
+const (
+	// StateOpen indicates the incident is open and nobody has acknowledged it
+	StateOpen State = "Open"
+	// StateAcknowledged indicates someone is looking into the incident
+	StateAcknowledged State = "Acknowledged"
+	// StateResolved indicates the incident is over
+	StateResolved State = "Resolved"
+)
+
+// SystemActor is recorded as the actor for automatic state changes
+const SystemActor = "system"
+
+var (
+	// ErrIncidentNotFound is returned when an incident does not exist
+	ErrIncidentNotFound = errors.New("incident not found")
+	// ErrIncidentResolved is returned when changing an incident that is already resolved
+	ErrIncidentResolved = errors.New("incident is already resolved")
+)
+
+// Note is a free-form comment attached to an incident
+type Note struct {
+	Author    string    `json:"author"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Incident records a period during which an application was degraded
+type Incident struct {
+	ID             string     `json:"id"`
+	Application    string     `json:"application"`
+	Namespace      string     `json:"namespace"`
+	State          State      `json:"state"`
+	Summary        string     `json:"summary"`
+	StartedAt      time.Time  `json:"startedAt"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt,omitempty"`
+	AcknowledgedBy string     `json:"acknowledgedBy,omitempty"`
+	ResolvedAt     *time.Time `json:"resolvedAt,omitempty"`
+	ResolvedBy     string     `json:"resolvedBy,omitempty"`
+	Notes          []Note     `json:"notes"`
+}
+
+// Active reports whether the incident has not been resolved yet
+func (i *Incident) Active() bool {
+	return i.State != StateResolved
+}
+
+// Filter narrows down the incidents returned by List
+type Filter struct {
+	// Application matches incidents of a single application
+	Application string
+	// State matches incidents in a single state
+	State State
+}
+
+// Tracker opens and resolves incidents as application health changes
+type Tracker struct {
+	mu        sync.RWMutex
+	incidents []*Incident
+	byID      map[string]*Incident
+	nextID    int
+	now       func() time.Time
+	logger    *slog.Logger
+}
+
+// NewTracker creates a new incident tracker that logs opened and resolved
+// incidents to logger
+func NewTracker(logger *slog.Logger) *Tracker {
+	return &Tracker{
+		byID:   make(map[string]*Incident),
+		now:    time.Now,
+		logger: logger,
+	}
+}
+
+// HandleStatusChange opens an incident when an application goes from Healthy
+// to Degraded and resolves the active incident once it is Healthy again. It
+// can be registered with kubernetes.Client.AddStatusListener.
+func (t *Tracker) HandleStatusChange(app kubernetes.Application, previous kubernetes.ApplicationStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	active := t.activeFor(app.Name)
+	switch {
+	case previous == kubernetes.ApplicationStatusHealthy && app.Status == kubernetes.ApplicationStatusDegraded:
+		if active != nil {
+			return
+		}
+		t.nextID++
+		incident := &Incident{
+			ID:          fmt.Sprintf("inc-%d", t.nextID),
+			Application: app.Name,
+			Namespace:   app.Namespace,
+			State:       StateOpen,
+			Summary:     fmt.Sprintf("%s changed from %s to %s", app.Name, previous, app.Status),
+			StartedAt:   t.now(),
+			Notes:       []Note{},
+		}
+		t.incidents = append(t.incidents, incident)
+		t.byID[incident.ID] = incident
+		t.logger.Warn("Incident opened", "incident", incident.ID, "application", app.Name, "namespace", app.Namespace)
+
+	case app.Status == kubernetes.ApplicationStatusHealthy:
+		if active == nil {
+			return
+		}
+		t.resolve(active, SystemActor)
+		t.logger.Info("Incident resolved", "incident", active.ID, "application", app.Name, "namespace", app.Namespace)
+	}
+}
+
+// activeFor returns the unresolved incident of an application, if any.
+// The caller must hold t.mu.
+func (t *Tracker) activeFor(application string) *Incident {
+	for i := len(t.incidents) - 1; i >= 0; i-- {
+		if t.incidents[i].Application == application && t.incidents[i].Active() {
+			return t.incidents[i]
+		}
+	}
+	return nil
+}
+
+// List returns the incidents matching filter, newest first
+func (t *Tracker) List(filter Filter) []Incident {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := []Incident{}
+	for i := len(t.incidents) - 1; i >= 0; i-- {
+		incident := t.incidents[i]
+		if filter.Application != "" && incident.Application != filter.Application {
+			continue
+		}
+		if filter.State != "" && incident.State != filter.State {
+			continue
+		}
+		result = append(result, copyIncident(incident))
+	}
+	return result
+}
+
+// Len returns the number of tracked incidents, including resolved ones
+func (t *Tracker) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.incidents)
+}
+
+// Get returns a single incident by ID
+func (t *Tracker) Get(id string) (Incident, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	incident, ok := t.byID[id]
+	if !ok {
+		return Incident{}, ErrIncidentNotFound
+	}
+	return copyIncident(incident), nil
+}
+
+// Acknowledge marks an incident as being handled by actor, optionally
+// attaching a note
+func (t *Tracker) Acknowledge(id, actor, note string) (Incident, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	incident, ok := t.byID[id]
+	if !ok {
+		return Incident{}, ErrIncidentNotFound
+	}
+	if !incident.Active() {
+		return Incident{}, ErrIncidentResolved
+	}
+
+	// Acknowledging twice keeps the original acknowledgement
+	if incident.State == StateOpen {
+		now := t.now()
+		incident.State = StateAcknowledged
+		incident.AcknowledgedAt = &now
+		incident.AcknowledgedBy = actor
+	}
+	t.addNote(incident, actor, note)
+
+	return copyIncident(incident), nil
+}
+
+// Resolve manually resolves an incident, optionally attaching a note
+func (t *Tracker) Resolve(id, actor, note string) (Incident, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	incident, ok := t.byID[id]
+	if !ok {
+		return Incident{}, ErrIncidentNotFound
+	}
+	if !incident.Active() {
+		return Incident{}, ErrIncidentResolved
+	}
+
+	t.addNote(incident, actor, note)
+	t.resolve(incident, actor)
+
+	return copyIncident(incident), nil
+}
+
+// AddNote attaches a note to an incident
+func (t *Tracker) AddNote(id, actor, note string) (Incident, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	incident, ok := t.byID[id]
+	if !ok {
+		return Incident{}, ErrIncidentNotFound
+	}
+	t.addNote(incident, actor, note)
+
+	return copyIncident(incident), nil
+}
+
+// resolve closes an incident. The caller must hold t.mu.
+func (t *Tracker) resolve(incident *Incident, actor string) {
+	now := t.now()
+	incident.State = StateResolved
+	incident.ResolvedAt = &now
+	incident.ResolvedBy = actor
+}
+
+// addNote appends a non-empty note to an incident. The caller must hold t.mu.
+func (t *Tracker) addNote(incident *Incident, actor, text string) {
+	if text == "" {
+		return
+	}
+	incident.Notes = append(incident.Notes, Note{
+		Author:    actor,
+		Text:      text,
+		CreatedAt: t.now(),
+	})
+}
+
+// copyIncident returns a copy of incident that does not share mutable state
+func copyIncident(incident *Incident) Incident {
+	copied := *incident
+	copied.Notes = append([]Note{}, incident.Notes...)
+	return copied
+}
@@ -1,14 +1,24 @@
 package kubernetes
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/sysintelligent/devops-bridge/server/tracing"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -40,26 +50,150 @@ const (
 
 // Application represents a Kubernetes application
 type Application struct {
-	ID         string            `json:"id"`
-	Name       string            `json:"name"`
-	Namespace  string            `json:"namespace"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Labels organize applications, e.g. by team, so that operations can
+	// select several of them at once
+	Labels     map[string]string `json:"labels,omitempty"`
 	Status     ApplicationStatus `json:"status"`
 	SyncStatus SyncStatus        `json:"syncStatus"`
-	CreatedAt  time.Time         `json:"createdAt"`
+	SyncPolicy SyncPolicy        `json:"syncPolicy"`
+	// DependsOn names the applications that must be healthy before this one
+	// is synced
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Source is where the desired state of the application comes from. Without
+	// a source, the sync status is only what was last stored.
+	Source *ApplicationSource `json:"source,omitempty"`
+	// TargetCommit is the commit the source revision resolved to at the last
+	// refresh
+	TargetCommit string `json:"targetCommit,omitempty"`
+	// SyncedCommit is the commit of the source the live state was last synced
+	// to or found to match
+	SyncedCommit string `json:"syncedCommit,omitempty"`
+	// ResourceVersion changes whenever the application is created or
+	// updated. An update that gives one only succeeds if it is current.
+	ResourceVersion string    `json:"resourceVersion,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
 }
 
+// ApplicationSource is where the manifests of an application are in a Git
+// repository: a directory of plain YAML manifests, a Helm chart or a
+// Kustomize overlay. Alternatively, the manifests are rendered from a
+// template of the catalog.
+type ApplicationSource struct {
+	// RepoURL is the URL of the Git repository
+	RepoURL string `json:"repoURL,omitempty"`
+	// Revision is the branch, tag or commit to deploy, HEAD if empty
+	Revision string `json:"revision,omitempty"`
+	// Path is relative to the repository root: a directory of manifests, a
+	// chart directory, a packaged chart (.tgz) or a directory with a
+	// kustomization.yaml
+	Path string `json:"path,omitempty"`
+	// Helm configures the rendering of a chart
+	Helm *HelmSource `json:"helm,omitempty"`
+	// Kustomize configures the rendering of a Kustomize overlay
+	Kustomize *KustomizeSource `json:"kustomize,omitempty"`
+	// Template renders the manifests from a catalog template instead of a
+	// Git repository
+	Template *TemplateSource `json:"template,omitempty"`
+}
+
+// TemplateSource selects a catalog template and its parameters. The commit
+// of a template source is a digest of the rendered manifests, so it changes
+// whenever the template or the parameters do.
+type TemplateSource struct {
+	// Name is the name of the template
+	Name string `json:"name"`
+	// Parameters are the values of the template parameters, as strings
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// HelmSource configures how a Helm chart is rendered
+type HelmSource struct {
+	// ReleaseName is the name of the release, the application name if empty
+	ReleaseName string `json:"releaseName,omitempty"`
+	// ValueFiles are values files relative to the chart, merged in order
+	ValueFiles []string `json:"valueFiles,omitempty"`
+	// Values is a YAML document of values merged over the values files
+	Values string `json:"values,omitempty"`
+}
+
+// KustomizeSource overrides settings of a Kustomize overlay
+type KustomizeSource struct {
+	// Images replace image names, tags or digests, as name=newName:tag,
+	// name:tag or name@digest
+	Images []string `json:"images,omitempty"`
+	// NamePrefix is prepended to the names of all objects
+	NamePrefix string `json:"namePrefix,omitempty"`
+	// CommonLabels are added to all objects and their selectors
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+}
+
+// SyncPolicy controls how the auto-sync scheduler treats an application
+// that is out of sync
+type SyncPolicy struct {
+	// AutoSync syncs the application when its desired state changes
+	AutoSync bool `json:"autoSync"`
+	// SelfHeal also syncs the application when its live state drifted from
+	// an unchanged desired state. It requires AutoSync.
+	SelfHeal bool `json:"selfHeal"`
+	// Prune deletes objects that are no longer part of the desired state
+	// when syncing. It requires AutoSync.
+	Prune bool `json:"prune"`
+}
+
+// ApplicationLabel is the label that ties Kubernetes objects to the
+// application they belong to
+const ApplicationLabel = "app.kubernetes.io/instance"
+
+// ErrApplicationNotFound is returned when an application does not exist
+var ErrApplicationNotFound = errors.New("application not found")
+
+// ErrApplicationExists is returned when creating an application whose name is already taken
+var ErrApplicationExists = errors.New("application already exists")
+
+// ErrConflict is returned when updating or deleting an application whose
+// resource version is not the one given, because it changed in the meantime
+var ErrConflict = errors.New("application has been modified")
+
+// StatusListener is notified after an application's health status changes
+type StatusListener func(app Application, previous ApplicationStatus)
+
 // Client is a Kubernetes client
 type Client struct {
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
+	// dynamic and mapper apply the manifests of application sources, which
+	// may be of any kind
+	dynamic dynamic.Interface
+	mapper  meta.ResettableRESTMapper
+	source  ManifestSource
+
+	// applications is an in-memory application store keyed by name.
+	// In a real implementation, applications would be stored as custom resources.
+	mu           sync.RWMutex
+	applications map[string]*Application
+	nextID       int
+	// version is the last resource version handed out
+	version   int
+	listeners []StatusListener
+
+	logger *slog.Logger
 }
 
-// NewClient creates a new Kubernetes client
-func NewClient() (*Client, error) {
-	// Try to use in-cluster config
-	config, err := rest.InClusterConfig()
-	if err != nil {
+// NewClient creates a new Kubernetes client that logs to logger. If
+// kubeconfig is empty, the in-cluster configuration is used when available
+// and ~/.kube/config otherwise. kubeContext, if set, selects the kubeconfig
+// context to use instead of the current one.
+func NewClient(logger *slog.Logger, kubeconfig, kubeContext string) (*Client, error) {
+	// Try to use in-cluster config unless a kubeconfig was asked for
+	var config *rest.Config
+	var err error
+	if kubeconfig == "" && kubeContext == "" {
+		config, err = rest.InClusterConfig()
+	}
+	if config == nil {
 		// Fall back to kubeconfig
-		kubeconfig := os.Getenv("KUBECONFIG")
 		if kubeconfig == "" {
 			home, err := os.UserHomeDir()
 			if err != nil {
@@ -69,96 +203,257 @@ func NewClient() (*Client, error) {
 		}
 
 		// Build config from kubeconfig
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+			&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+		).ClientConfig()
 		if err != nil {
 			return nil, fmt.Errorf("failed to build config from kubeconfig: %w", err)
 		}
 	}
 
+	// Trace the requests made to the API server
+	config.Wrap(tracing.Transport)
+
 	// Create clientset
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Kubernetes clientset: %w", err)
 	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes dynamic client: %w", err)
+	}
+
+	c := &Client{
+		clientset:    clientset,
+		dynamic:      dynamicClient,
+		mapper:       restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery())),
+		applications: make(map[string]*Application),
+		logger:       logger,
+	}
+	c.seedApplications()
+
+	return c, nil
+}
 
-	return &Client{
-		clientset: clientset,
-	}, nil
+// Clientset returns the underlying Kubernetes clientset
+func (c *Client) Clientset() kubernetes.Interface {
+	return c.clientset
 }
 
-// GetApplications returns a list of all applications
-func (c *Client) GetApplications() ([]*Application, error) {
-	// This is a placeholder implementation
-	// In a real implementation, this would query Kubernetes for applications
+// Ping checks that the Kubernetes API server is reachable with a discovery
+// call
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.clientset.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error(); err != nil {
+		return fmt.Errorf("failed to reach Kubernetes API server: %w", err)
+	}
+	return nil
+}
 
-	// For demonstration purposes, we'll return some dummy data
-	return []*Application{
+// seedApplications populates the store with demonstration data
+func (c *Client) seedApplications() {
+	for _, app := range []*Application{
 		{
-			ID:         "app-1",
 			Name:       "frontend",
 			Namespace:  "default",
+			Labels:     map[string]string{"team": "web"},
 			Status:     ApplicationStatusHealthy,
 			SyncStatus: SyncStatusSynced,
 			CreatedAt:  time.Now().Add(-24 * time.Hour),
 		},
 		{
-			ID:         "app-2",
 			Name:       "backend",
 			Namespace:  "default",
+			Labels:     map[string]string{"team": "payments"},
+			DependsOn:  []string{"database"},
 			Status:     ApplicationStatusHealthy,
 			SyncStatus: SyncStatusSynced,
 			CreatedAt:  time.Now().Add(-48 * time.Hour),
 		},
 		{
-			ID:         "app-3",
 			Name:       "database",
 			Namespace:  "default",
+			Labels:     map[string]string{"team": "payments"},
 			Status:     ApplicationStatusDegraded,
 			SyncStatus: SyncStatusOutOfSync,
 			CreatedAt:  time.Now().Add(-72 * time.Hour),
 		},
-	}, nil
+	} {
+		c.nextID++
+		app.ID = fmt.Sprintf("app-%d", c.nextID)
+		app.ResourceVersion = c.nextVersion()
+		c.applications[app.Name] = app
+	}
+}
+
+// AddStatusListener registers a listener that is called whenever an
+// application's health status changes
+func (c *Client) AddStatusListener(listener StatusListener) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
+
+// notifyStatusChange calls all registered status listeners
+func (c *Client) notifyStatusChange(app Application, previous ApplicationStatus) {
+	c.mu.RLock()
+	listeners := append([]StatusListener(nil), c.listeners...)
+	c.mu.RUnlock()
+
+	c.logger.Info("Application status changed", "application", app.Name, "namespace", app.Namespace,
+		"from", previous, "to", app.Status)
+	for _, listener := range listeners {
+		listener(app, previous)
+	}
+}
+
+// GetApplications returns a list of all applications, newest first
+func (c *Client) GetApplications(ctx context.Context) ([]*Application, error) {
+	_, span := startSpan(ctx, "GetApplications", "")
+	defer span.End()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	apps := make([]*Application, 0, len(c.applications))
+	for _, app := range c.applications {
+		copied := *app
+		apps = append(apps, &copied)
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].CreatedAt.After(apps[j].CreatedAt)
+	})
+
+	return apps, nil
 }
 
 // GetApplication returns a single application by name
-func (c *Client) GetApplication(name string) (*Application, error) {
-	// This is a placeholder implementation
-	// In a real implementation, this would query Kubernetes for the application
-
-	// For demonstration purposes, we'll return some dummy data
-	apps, _ := c.GetApplications()
-	for _, app := range apps {
-		if app.Name == name {
-			return app, nil
-		}
+func (c *Client) GetApplication(ctx context.Context, name string) (_ *Application, err error) {
+	_, span := startSpan(ctx, "GetApplication", name)
+	defer func() { tracing.End(span, err) }()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	app, ok := c.applications[name]
+	if !ok {
+		return nil, ErrApplicationNotFound
 	}
 
-	return nil, errors.New("application not found")
+	copied := *app
+	return &copied, nil
 }
 
-// CreateApplication creates a new application
-func (c *Client) CreateApplication(app *Application) error {
-	// This is a placeholder implementation
-	// In a real implementation, this would create the application in Kubernetes
+// CreateApplication creates a new application. Server-assigned fields are
+// written back to app.
+func (c *Client) CreateApplication(ctx context.Context, app *Application) (err error) {
+	_, span := startSpan(ctx, "CreateApplication", app.Name)
+	defer func() { tracing.End(span, err) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := app.Validate(); err != nil {
+		return err
+	}
+	if _, ok := c.applications[app.Name]; ok {
+		return ErrApplicationExists
+	}
+	if err := c.checkDependencyCycle(app); err != nil {
+		return err
+	}
+	if err := c.checkSource(app); err != nil {
+		return err
+	}
+
+	// Fill in server-assigned fields, ignoring those given by the client
+	c.nextID++
+	app.ID = fmt.Sprintf("app-%d", c.nextID)
+	app.ResourceVersion = c.nextVersion()
+	app.CreatedAt = time.Now()
+	app.Status = ApplicationStatusUnknown
+	app.SyncStatus = SyncStatusUnknown
+	app.TargetCommit = ""
+	app.SyncedCommit = ""
 
-	// For demonstration purposes, we'll just return success
+	stored := *app
+	c.applications[app.Name] = &stored
 	return nil
 }
 
-// UpdateApplication updates an existing application
-func (c *Client) UpdateApplication(name string, app *Application) error {
-	// This is a placeholder implementation
-	// In a real implementation, this would update the application in Kubernetes
+// UpdateApplication updates an existing application. Server-assigned fields
+// are written back to app. If app has a resource version, it must be the
+// current one, or ErrConflict is returned.
+func (c *Client) UpdateApplication(ctx context.Context, name string, app *Application) (err error) {
+	_, span := startSpan(ctx, "UpdateApplication", name)
+	defer func() { tracing.End(span, err) }()
+
+	c.mu.Lock()
+	existing, ok := c.applications[name]
+	if !ok {
+		c.mu.Unlock()
+		return ErrApplicationNotFound
+	}
+	if app.ResourceVersion != "" && app.ResourceVersion != existing.ResourceVersion {
+		c.mu.Unlock()
+		return ErrConflict
+	}
+
+	// Preserve identity and server-assigned fields, ignoring those given by
+	// the client
+	app.ID = existing.ID
+	app.Name = existing.Name
+	app.CreatedAt = existing.CreatedAt
+	if app.Namespace == "" {
+		app.Namespace = existing.Namespace
+	}
+	app.Status = existing.Status
+	app.SyncStatus = existing.SyncStatus
+	app.TargetCommit = existing.TargetCommit
+	app.SyncedCommit = existing.SyncedCommit
+	if err := app.Validate(); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	if err := c.checkDependencyCycle(app); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	if err := c.checkSource(app); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	app.ResourceVersion = c.nextVersion()
 
-	// For demonstration purposes, we'll just return success
+	stored := *app
+	c.applications[name] = &stored
+	c.mu.Unlock()
 	return nil
 }
 
-// DeleteApplication deletes an application
-func (c *Client) DeleteApplication(name string) error {
-	// This is a placeholder implementation
-	// In a real implementation, this would delete the application from Kubernetes
+// DeleteApplication deletes an application. If resourceVersion is set, it
+// must be the current one, or ErrConflict is returned.
+func (c *Client) DeleteApplication(ctx context.Context, name, resourceVersion string) (err error) {
+	_, span := startSpan(ctx, "DeleteApplication", name)
+	defer func() { tracing.End(span, err) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	// For demonstration purposes, we'll just return success
+	existing, ok := c.applications[name]
+	if !ok {
+		return ErrApplicationNotFound
+	}
+	if resourceVersion != "" && resourceVersion != existing.ResourceVersion {
+		return ErrConflict
+	}
+	delete(c.applications, name)
 	return nil
 }
+
+// nextVersion returns a new resource version. c.mu must be held.
+func (c *Client) nextVersion() string {
+	c.version++
+	return strconv.Itoa(c.version)
+}
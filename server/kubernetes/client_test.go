@@ -0,0 +1,66 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// newTestClient creates a client that only has the in-memory application
+// store, holding apps
+func newTestClient(apps ...*Application) *Client {
+	c := &Client{
+		applications: make(map[string]*Application, len(apps)),
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	for _, app := range apps {
+		c.applications[app.Name] = app
+	}
+	return c
+}
+
+// checkingSource is a manifest source that fails checks and renders with err
+type checkingSource struct{ err error }
+
+func (s checkingSource) Render(context.Context, *Application) ([]*unstructured.Unstructured, string, error) {
+	return nil, "", s.err
+}
+
+func (s checkingSource) CheckSource(*Application) error { return s.err }
+
+func TestCreateApplicationChecksSource(t *testing.T) {
+	template := &ApplicationSource{Template: &TemplateSource{Name: "web-service"}}
+	errRejected := errors.New("missing parameter image")
+	for _, tt := range []struct {
+		name   string
+		source ManifestSource
+		want   error
+	}{
+		{"rejected", checkingSource{errRejected}, errRejected},
+		{"no manifest source", nil, ErrNoManifestSource},
+		{"accepted", checkingSource{}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient()
+			c.source = tt.source
+
+			err := c.CreateApplication(context.Background(), &Application{Name: "web", Namespace: "default", Source: template})
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("CreateApplication() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidSource) || !errors.Is(err, tt.want) {
+				t.Errorf("CreateApplication() error = %v, want an invalid source wrapping %v", err, tt.want)
+			}
+			if _, ok := c.applications["web"]; ok {
+				t.Error("application with an invalid source was stored")
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrBlocked is returned when syncing an application whose dependencies
+// are not healthy yet
+var ErrBlocked = errors.New("application is blocked by its dependencies")
+
+// BlockedError lists the dependencies that block an application from
+// syncing. It matches ErrBlocked.
+type BlockedError struct {
+	Application string
+	BlockedBy   []string
+}
+
+// Error implements the error interface
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("application %s is blocked by %s", e.Application, strings.Join(e.BlockedBy, ", "))
+}
+
+// Is makes errors.Is match ErrBlocked
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+// DependencyGraph shows how applications depend on each other
+type DependencyGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	// Edges point from an application to one of its dependencies
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is an application in the dependency graph
+type GraphNode struct {
+	Name       string            `json:"name"`
+	Namespace  string            `json:"namespace"`
+	Status     ApplicationStatus `json:"status"`
+	SyncStatus SyncStatus        `json:"syncStatus"`
+	// Wave is 0 for applications without dependencies, and otherwise one
+	// more than the highest wave of their dependencies
+	Wave      int      `json:"wave"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// BlockedBy lists the dependencies that are missing or not healthy, so
+	// the application is not synced
+	BlockedBy []string `json:"blockedBy,omitempty"`
+}
+
+// GraphEdge is a dependency of one application on another
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Missing is set when the dependency does not exist
+	Missing bool `json:"missing,omitempty"`
+}
+
+// GetDependencyGraph returns the dependency graph of all applications,
+// ordered by wave and name
+func (c *Client) GetDependencyGraph() (*DependencyGraph, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	waves := make(map[string]int, len(c.applications))
+	graph := &DependencyGraph{Nodes: make([]GraphNode, 0, len(c.applications)), Edges: []GraphEdge{}}
+	for _, app := range c.applications {
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			Name:       app.Name,
+			Namespace:  app.Namespace,
+			Status:     app.Status,
+			SyncStatus: app.SyncStatus,
+			Wave:       c.wave(app.Name, waves, map[string]bool{}),
+			DependsOn:  app.DependsOn,
+			BlockedBy:  c.blockedBy(app),
+		})
+		for _, dep := range app.DependsOn {
+			_, ok := c.applications[dep]
+			graph.Edges = append(graph.Edges, GraphEdge{From: app.Name, To: dep, Missing: !ok})
+		}
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		a, b := graph.Nodes[i], graph.Nodes[j]
+		if a.Wave != b.Wave {
+			return a.Wave < b.Wave
+		}
+		return a.Name < b.Name
+	})
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return graph, nil
+}
+
+// checkDependencies returns a *BlockedError if a dependency of app is
+// missing or not healthy
+func (c *Client) checkDependencies(app *Application) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if blockedBy := c.blockedBy(app); len(blockedBy) > 0 {
+		return &BlockedError{Application: app.Name, BlockedBy: blockedBy}
+	}
+	return nil
+}
+
+// blockedBy returns the dependencies of app that are missing or not
+// healthy. c.mu must be held.
+func (c *Client) blockedBy(app *Application) []string {
+	var result []string
+	for _, dep := range app.DependsOn {
+		if existing, ok := c.applications[dep]; !ok || existing.Status != ApplicationStatusHealthy {
+			result = append(result, dep)
+		}
+	}
+	return result
+}
+
+// wave returns the sync wave of the application called name, memoized in
+// waves. Missing applications are in wave 0. c.mu must be held.
+func (c *Client) wave(name string, waves map[string]int, visiting map[string]bool) int {
+	if wave, ok := waves[name]; ok {
+		return wave
+	}
+	app, ok := c.applications[name]
+	if !ok || visiting[name] {
+		// Cycles are rejected when storing applications, but don't recurse
+		// forever on one
+		return 0
+	}
+	visiting[name] = true
+	wave := 0
+	for _, dep := range app.DependsOn {
+		wave = max(wave, c.wave(dep, waves, visiting)+1)
+	}
+	delete(visiting, name)
+	waves[name] = wave
+	return wave
+}
+
+// dependencyCycle returns the cycle app would close among the stored
+// applications, e.g. [a b a], or nil if there is none. c.mu must be held.
+func (c *Client) dependencyCycle(app *Application) []string {
+	dependsOn := func(name string) []string {
+		if name == app.Name {
+			return app.DependsOn
+		}
+		if existing, ok := c.applications[name]; ok {
+			return existing.DependsOn
+		}
+		return nil
+	}
+
+	var path []string
+	visited := make(map[string]bool)
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		path = append(path, name)
+		if name == app.Name && len(path) > 1 {
+			return true
+		}
+		if !visited[name] {
+			visited[name] = true
+			for _, dep := range dependsOn(name) {
+				if visit(dep) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if visit(app.Name) {
+		return path
+	}
+	return nil
+}
+
+// checkDependencyCycle returns a *ValidationError if storing app would
+// create a dependency cycle. c.mu must be held.
+func (c *Client) checkDependencyCycle(app *Application) error {
+	if cycle := c.dependencyCycle(app); cycle != nil {
+		return &ValidationError{Fields: []FieldError{
+			{Field: "dependsOn", Message: "creates a cycle: " + strings.Join(cycle, " -> ")},
+		}}
+	}
+	return nil
+}
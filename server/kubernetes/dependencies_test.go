@@ -0,0 +1,97 @@
+package kubernetes
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// app creates an application for dependency tests
+func app(name string, status ApplicationStatus, dependsOn ...string) *Application {
+	return &Application{Name: name, Namespace: "default", Status: status, DependsOn: dependsOn}
+}
+
+func TestDependencyCycle(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		stored []*Application
+		app    *Application
+		want   []string
+	}{
+		{"no dependencies", nil, app("a", ""), nil},
+		{"chain", []*Application{app("b", "", "c"), app("c", "")}, app("a", "", "b"), nil},
+		{"diamond", []*Application{app("b", "", "d"), app("c", "", "d"), app("d", "")}, app("a", "", "b", "c"), nil},
+		{"missing dependency", nil, app("a", "", "b"), nil},
+		{"direct cycle", []*Application{app("b", "", "a")}, app("a", "", "b"), []string{"a", "b", "a"}},
+		{"indirect cycle", []*Application{app("b", "", "c"), app("c", "", "a")}, app("a", "", "b"), []string{"a", "b", "c", "a"}},
+		{"update closing a cycle", []*Application{app("a", ""), app("b", "", "a")}, app("a", "", "b"), []string{"a", "b", "a"}},
+		{"cycle not through the application", []*Application{app("b", "", "c"), app("c", "", "b")}, app("a", "", "b"), nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(tt.stored...)
+			if got := c.dependencyCycle(tt.app); !slices.Equal(got, tt.want) {
+				t.Errorf("dependencyCycle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDependencyGraph(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		apps  []*Application
+		nodes []GraphNode
+		edges []GraphEdge
+	}{
+		{
+			name:  "empty",
+			nodes: []GraphNode{},
+			edges: []GraphEdge{},
+		},
+		{
+			name: "waves",
+			apps: []*Application{
+				app("frontend", ApplicationStatusHealthy, "backend"),
+				app("backend", ApplicationStatusHealthy, "database", "cache"),
+				app("database", ApplicationStatusDegraded),
+				app("cache", ApplicationStatusHealthy),
+			},
+			nodes: []GraphNode{
+				{Name: "cache", Namespace: "default", Status: ApplicationStatusHealthy, Wave: 0},
+				{Name: "database", Namespace: "default", Status: ApplicationStatusDegraded, Wave: 0},
+				{Name: "backend", Namespace: "default", Status: ApplicationStatusHealthy, Wave: 1,
+					DependsOn: []string{"database", "cache"}, BlockedBy: []string{"database"}},
+				{Name: "frontend", Namespace: "default", Status: ApplicationStatusHealthy, Wave: 2,
+					DependsOn: []string{"backend"}},
+			},
+			edges: []GraphEdge{
+				{From: "backend", To: "cache"},
+				{From: "backend", To: "database"},
+				{From: "frontend", To: "backend"},
+			},
+		},
+		{
+			name: "missing dependency",
+			apps: []*Application{app("backend", ApplicationStatusHealthy, "database")},
+			nodes: []GraphNode{
+				// A missing dependency counts as wave 0
+				{Name: "backend", Namespace: "default", Status: ApplicationStatusHealthy, Wave: 1,
+					DependsOn: []string{"database"}, BlockedBy: []string{"database"}},
+			},
+			edges: []GraphEdge{{From: "backend", To: "database", Missing: true}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, err := newTestClient(tt.apps...).GetDependencyGraph()
+			if err != nil {
+				t.Fatalf("GetDependencyGraph() error = %v", err)
+			}
+			if !reflect.DeepEqual(graph.Nodes, tt.nodes) {
+				t.Errorf("GetDependencyGraph() nodes = %+v, want %+v", graph.Nodes, tt.nodes)
+			}
+			if !reflect.DeepEqual(graph.Edges, tt.edges) {
+				t.Errorf("GetDependencyGraph() edges = %+v, want %+v", graph.Edges, tt.edges)
+			}
+		})
+	}
+}
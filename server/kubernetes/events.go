@@ -0,0 +1,139 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sysintelligent/devops-bridge/server/tracing"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Event is a deduplicated Kubernetes event about an object of an application
+type Event struct {
+	Type       string    `json:"type"`
+	Reason     string    `json:"reason"`
+	Message    string    `json:"message"`
+	ObjectKind string    `json:"objectKind"`
+	ObjectName string    `json:"objectName"`
+	Count      int32     `json:"count"`
+	FirstSeen  time.Time `json:"firstSeen"`
+	LastSeen   time.Time `json:"lastSeen"`
+}
+
+// GetApplicationEvents returns the events of all objects of an application.
+// Events with the same object, type, reason and message are merged into one,
+// and the result is sorted newest first. If eventType is not empty, only
+// events of that type (Normal or Warning) are returned.
+func (c *Client) GetApplicationEvents(ctx context.Context, name, eventType string) (_ []Event, err error) {
+	ctx, span := startSpan(ctx, "GetApplicationEvents", name)
+	defer func() { tracing.End(span, err) }()
+
+	app, err := c.GetApplication(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := c.applicationObjects(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	owned := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		owned[obj.key()] = true
+	}
+
+	list, err := c.clientset.CoreV1().Events(app.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	// Merge duplicate events of owned objects
+	merged := make(map[string]*Event)
+	for i := range list.Items {
+		item := &list.Items[i]
+		involved := item.InvolvedObject
+		if !owned[involved.Kind+"/"+involved.Name] {
+			continue
+		}
+		if eventType != "" && item.Type != eventType {
+			continue
+		}
+
+		first, last := eventTimes(item)
+		count := eventCount(item)
+		key := fmt.Sprintf("%s/%s/%s/%s/%s", involved.Kind, involved.Name, item.Type, item.Reason, item.Message)
+		if event, ok := merged[key]; ok {
+			event.Count += count
+			if first.Before(event.FirstSeen) {
+				event.FirstSeen = first
+			}
+			if last.After(event.LastSeen) {
+				event.LastSeen = last
+			}
+			continue
+		}
+		merged[key] = &Event{
+			Type:       item.Type,
+			Reason:     item.Reason,
+			Message:    item.Message,
+			ObjectKind: involved.Kind,
+			ObjectName: involved.Name,
+			Count:      count,
+			FirstSeen:  first,
+			LastSeen:   last,
+		}
+	}
+
+	events := make([]Event, 0, len(merged))
+	for _, event := range merged {
+		events = append(events, *event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].LastSeen.Equal(events[j].LastSeen) {
+			return events[i].LastSeen.After(events[j].LastSeen)
+		}
+		if events[i].ObjectName != events[j].ObjectName {
+			return events[i].ObjectName < events[j].ObjectName
+		}
+		return events[i].Reason < events[j].Reason
+	})
+
+	return events, nil
+}
+
+// eventTimes returns when an event was first and last seen, falling back to
+// the newer EventTime and Series fields when the legacy timestamps are unset
+func eventTimes(event *corev1.Event) (time.Time, time.Time) {
+	first := event.FirstTimestamp.Time
+	last := event.LastTimestamp.Time
+	if first.IsZero() {
+		first = event.EventTime.Time
+	}
+	if first.IsZero() {
+		first = event.CreationTimestamp.Time
+	}
+	if event.Series != nil && event.Series.LastObservedTime.After(last) {
+		last = event.Series.LastObservedTime.Time
+	}
+	if last.IsZero() {
+		last = first
+	}
+	if first.IsZero() {
+		first = last
+	}
+	return first, last
+}
+
+// eventCount returns how often an event occurred
+func eventCount(event *corev1.Event) int32 {
+	if event.Series != nil && event.Series.Count > 0 {
+		return event.Series.Count
+	}
+	if event.Count > 0 {
+		return event.Count
+	}
+	return 1
+}
@@ -0,0 +1,201 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// healthOrder ranks health statuses from best to worst, so the health of an
+// application is that of its least healthy object
+var healthOrder = map[ApplicationStatus]int{
+	ApplicationStatusHealthy:     0,
+	ApplicationStatusSuspended:   1,
+	ApplicationStatusProgressing: 2,
+	ApplicationStatusDegraded:    3,
+}
+
+// applicationHealth assesses the health of an application from the objects
+// carrying its label, as returned by applicationObjects. Objects owned by
+// others, such as Pods, count through their owners. Without objects, the
+// health is unknown.
+func applicationHealth(app *Application, objects []ownedObject) ApplicationStatus {
+	health := ApplicationStatusUnknown
+	for _, obj := range objects {
+		if obj.Labels[ApplicationLabel] != app.Name || len(obj.Owners) > 0 {
+			continue
+		}
+		status, _ := objectHealth(obj.Object)
+		if health == ApplicationStatusUnknown || healthOrder[status] > healthOrder[health] {
+			health = status
+		}
+	}
+	return health
+}
+
+// objectHealth assesses the health of a typed Kubernetes object and returns
+// a short explanation when it is not healthy
+func objectHealth(object interface{}) (ApplicationStatus, string) {
+	switch obj := object.(type) {
+	case *appsv1.Deployment:
+		return deploymentHealth(obj)
+	case *appsv1.StatefulSet:
+		return statefulSetHealth(obj)
+	case *appsv1.DaemonSet:
+		return daemonSetHealth(obj)
+	case *appsv1.ReplicaSet:
+		return replicaSetHealth(obj)
+	case *batchv1.Job:
+		return jobHealth(obj)
+	case *corev1.Pod:
+		return podHealth(obj)
+	case *corev1.Service:
+		return serviceHealth(obj)
+	case *corev1.PersistentVolumeClaim:
+		return pvcHealth(obj)
+	default:
+		// Objects without a status, e.g. ConfigMaps, are healthy once they exist
+		return ApplicationStatusHealthy, ""
+	}
+}
+
+// deploymentHealth assesses the health of a Deployment
+func deploymentHealth(d *appsv1.Deployment) (ApplicationStatus, string) {
+	if d.Spec.Paused {
+		return ApplicationStatusSuspended, "rollout is paused"
+	}
+	for _, cond := range d.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			return ApplicationStatusDegraded, cond.Message
+		}
+	}
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	switch {
+	case d.Status.ObservedGeneration < d.Generation:
+		return ApplicationStatusProgressing, "waiting for rollout to be observed"
+	case d.Status.UpdatedReplicas < replicas:
+		return ApplicationStatusProgressing, fmt.Sprintf("%d of %d replicas updated", d.Status.UpdatedReplicas, replicas)
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		return ApplicationStatusProgressing, fmt.Sprintf("%d old replicas pending termination", d.Status.Replicas-d.Status.UpdatedReplicas)
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		return ApplicationStatusProgressing, fmt.Sprintf("%d of %d updated replicas available", d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	}
+	return ApplicationStatusHealthy, ""
+}
+
+// statefulSetHealth assesses the health of a StatefulSet
+func statefulSetHealth(s *appsv1.StatefulSet) (ApplicationStatus, string) {
+	replicas := int32(1)
+	if s.Spec.Replicas != nil {
+		replicas = *s.Spec.Replicas
+	}
+	switch {
+	case s.Status.ObservedGeneration < s.Generation:
+		return ApplicationStatusProgressing, "waiting for rollout to be observed"
+	case s.Status.ReadyReplicas < replicas:
+		return ApplicationStatusProgressing, fmt.Sprintf("%d of %d replicas ready", s.Status.ReadyReplicas, replicas)
+	case s.Status.UpdateRevision != "" && s.Status.CurrentRevision != s.Status.UpdateRevision:
+		return ApplicationStatusProgressing, "rolling update in progress"
+	}
+	return ApplicationStatusHealthy, ""
+}
+
+// daemonSetHealth assesses the health of a DaemonSet
+func daemonSetHealth(d *appsv1.DaemonSet) (ApplicationStatus, string) {
+	switch {
+	case d.Status.ObservedGeneration < d.Generation:
+		return ApplicationStatusProgressing, "waiting for rollout to be observed"
+	case d.Status.UpdatedNumberScheduled < d.Status.DesiredNumberScheduled:
+		return ApplicationStatusProgressing, fmt.Sprintf("%d of %d pods updated", d.Status.UpdatedNumberScheduled, d.Status.DesiredNumberScheduled)
+	case d.Status.NumberAvailable < d.Status.DesiredNumberScheduled:
+		return ApplicationStatusProgressing, fmt.Sprintf("%d of %d pods available", d.Status.NumberAvailable, d.Status.DesiredNumberScheduled)
+	}
+	return ApplicationStatusHealthy, ""
+}
+
+// replicaSetHealth assesses the health of a ReplicaSet
+func replicaSetHealth(r *appsv1.ReplicaSet) (ApplicationStatus, string) {
+	replicas := int32(1)
+	if r.Spec.Replicas != nil {
+		replicas = *r.Spec.Replicas
+	}
+	if r.Status.ReadyReplicas < replicas {
+		return ApplicationStatusProgressing, fmt.Sprintf("%d of %d replicas ready", r.Status.ReadyReplicas, replicas)
+	}
+	return ApplicationStatusHealthy, ""
+}
+
+// jobHealth assesses the health of a Job
+func jobHealth(j *batchv1.Job) (ApplicationStatus, string) {
+	for _, cond := range j.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobFailed:
+			return ApplicationStatusDegraded, cond.Message
+		case batchv1.JobComplete:
+			return ApplicationStatusHealthy, ""
+		case batchv1.JobSuspended:
+			return ApplicationStatusSuspended, "job is suspended"
+		}
+	}
+	return ApplicationStatusProgressing, "job is running"
+}
+
+// podHealth assesses the health of a Pod
+func podHealth(p *corev1.Pod) (ApplicationStatus, string) {
+	// Containers stuck in a failing state make the pod degraded
+	for _, cs := range append(p.Status.InitContainerStatuses, p.Status.ContainerStatuses...) {
+		if cs.State.Waiting == nil {
+			continue
+		}
+		switch cs.State.Waiting.Reason {
+		case "CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull", "CreateContainerConfigError", "InvalidImageName":
+			return ApplicationStatusDegraded, fmt.Sprintf("container %s: %s", cs.Name, cs.State.Waiting.Reason)
+		}
+	}
+
+	switch p.Status.Phase {
+	case corev1.PodSucceeded:
+		return ApplicationStatusHealthy, ""
+	case corev1.PodFailed:
+		return ApplicationStatusDegraded, p.Status.Message
+	case corev1.PodPending:
+		return ApplicationStatusProgressing, "pod is pending"
+	case corev1.PodRunning:
+		for _, cond := range p.Status.Conditions {
+			if cond.Type == corev1.PodReady && cond.Status != corev1.ConditionTrue {
+				return ApplicationStatusProgressing, "pod is not ready"
+			}
+		}
+		return ApplicationStatusHealthy, ""
+	}
+	return ApplicationStatusUnknown, ""
+}
+
+// serviceHealth assesses the health of a Service
+func serviceHealth(s *corev1.Service) (ApplicationStatus, string) {
+	if s.Spec.Type == corev1.ServiceTypeLoadBalancer && len(s.Status.LoadBalancer.Ingress) == 0 {
+		return ApplicationStatusProgressing, "waiting for load balancer"
+	}
+	return ApplicationStatusHealthy, ""
+}
+
+// pvcHealth assesses the health of a PersistentVolumeClaim
+func pvcHealth(p *corev1.PersistentVolumeClaim) (ApplicationStatus, string) {
+	switch p.Status.Phase {
+	case corev1.ClaimBound:
+		return ApplicationStatusHealthy, ""
+	case corev1.ClaimLost:
+		return ApplicationStatusDegraded, "claim lost its volume"
+	default:
+		return ApplicationStatusProgressing, "claim is pending"
+	}
+}
@@ -0,0 +1,39 @@
+package kubernetes
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestApplicationHealth(t *testing.T) {
+	app := &Application{Name: "shop", Namespace: "default"}
+	labels := map[string]string{ApplicationLabel: "shop"}
+	configMap := ownedObject{Kind: "ConfigMap", Name: "settings", Labels: labels, Object: &corev1.ConfigMap{}}
+	pendingClaim := ownedObject{Kind: "PersistentVolumeClaim", Name: "data", Labels: labels, Object: &corev1.PersistentVolumeClaim{}}
+	lostClaim := ownedObject{Kind: "PersistentVolumeClaim", Name: "data", Labels: labels,
+		Object: &corev1.PersistentVolumeClaim{Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimLost}}}
+
+	for _, tt := range []struct {
+		name    string
+		objects []ownedObject
+		want    ApplicationStatus
+	}{
+		{"no objects", nil, ApplicationStatusUnknown},
+		{"healthy", []ownedObject{configMap}, ApplicationStatusHealthy},
+		{"least healthy object", []ownedObject{configMap, lostClaim, pendingClaim}, ApplicationStatusDegraded},
+		{"owned objects count through their owners", []ownedObject{configMap, {
+			Kind: "PersistentVolumeClaim", Name: "owned", Labels: labels, Owners: []types.UID{"uid"}, Object: lostClaim.Object,
+		}}, ApplicationStatusHealthy},
+		{"objects of other applications", []ownedObject{configMap, {
+			Kind: "PersistentVolumeClaim", Name: "other", Labels: map[string]string{ApplicationLabel: "other"}, Object: lostClaim.Object,
+		}}, ApplicationStatusHealthy},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applicationHealth(app, tt.objects); got != tt.want {
+				t.Errorf("applicationHealth() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package kubernetes
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sysintelligent/devops-bridge/server/tracing"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogOptions controls which log lines are returned for an application
+type LogOptions struct {
+	// Container restricts logs to containers with this name
+	Container string
+	// Since only returns lines newer than this duration, if non-zero
+	Since time.Duration
+	// TailLines only returns this many lines per container, if positive
+	TailLines int64
+	// Follow keeps streaming new lines until the context is cancelled
+	Follow bool
+}
+
+// LogLine is a single log line of an application container
+type LogLine struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Line      string `json:"line"`
+}
+
+// StreamApplicationLogs streams the logs of all pods of an application to
+// emit. Without Follow, containers are read one after another; with Follow
+// they are read concurrently and emit is called from one goroutine at a time.
+func (c *Client) StreamApplicationLogs(ctx context.Context, name string, opts LogOptions, emit func(LogLine) error) (err error) {
+	ctx, span := startSpan(ctx, "StreamApplicationLogs", name)
+	defer func() { tracing.End(span, err) }()
+
+	app, err := c.GetApplication(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	// Find the pods of the application
+	pods, err := c.clientset.CoreV1().Pods(app.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: ApplicationLabel + "=" + app.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	// Collect the containers to read from
+	type target struct{ pod, container string }
+	var targets []target
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			if opts.Container == "" || opts.Container == container.Name {
+				targets = append(targets, target{pod.Name, container.Name})
+			}
+		}
+	}
+
+	if !opts.Follow {
+		for _, t := range targets {
+			if err := c.streamContainerLogs(ctx, app.Namespace, t.pod, t.container, opts, emit); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Follow all containers at once, serializing calls to emit
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	serialized := func(line LogLine) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return emit(line)
+	}
+	for _, t := range targets {
+		wg.Add(1)
+		go func(pod, container string) {
+			defer wg.Done()
+			if err := c.streamContainerLogs(ctx, app.Namespace, pod, container, opts, serialized); err != nil && ctx.Err() == nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}(t.pod, t.container)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// streamContainerLogs streams the logs of a single container to emit
+func (c *Client) streamContainerLogs(ctx context.Context, namespace, pod, container string, opts LogOptions, emit func(LogLine) error) error {
+	podOpts := &corev1.PodLogOptions{
+		Container: container,
+		Follow:    opts.Follow,
+	}
+	if opts.Since > 0 {
+		// The API server takes whole seconds and rejects 0, so round up
+		seconds := int64((opts.Since + time.Second - 1) / time.Second)
+		podOpts.SinceSeconds = &seconds
+	}
+	if opts.TailLines > 0 {
+		podOpts.TailLines = &opts.TailLines
+	}
+
+	stream, err := c.clientset.CoreV1().Pods(namespace).GetLogs(pod, podOpts).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get logs of %s/%s: %w", pod, container, err)
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := emit(LogLine{Pod: pod, Container: container, Line: scanner.Text()}); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read logs of %s/%s: %w", pod, container, err)
+	}
+	return nil
+}
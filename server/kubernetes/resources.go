@@ -0,0 +1,187 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ownedObject is a Kubernetes object that belongs to an application
+type ownedObject struct {
+	Kind      string
+	Namespace string
+	Name      string
+	UID       types.UID
+	Owners    []types.UID
+	Labels    map[string]string
+	// Object is the typed Kubernetes object, e.g. *appsv1.Deployment
+	Object interface{}
+}
+
+// objectLister lists all objects of one kind in a namespace
+type objectLister struct {
+	kind string
+	list func(ctx context.Context, namespace string) ([]ownedObject, error)
+}
+
+// objectListers returns the listers for every kind an application may own
+func (c *Client) objectListers() []objectLister {
+	return []objectLister{
+		{"Deployment", func(ctx context.Context, ns string) ([]ownedObject, error) {
+			list, err := c.clientset.AppsV1().Deployments(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toOwnedObjects("Deployment", list.Items), nil
+		}},
+		{"StatefulSet", func(ctx context.Context, ns string) ([]ownedObject, error) {
+			list, err := c.clientset.AppsV1().StatefulSets(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toOwnedObjects("StatefulSet", list.Items), nil
+		}},
+		{"DaemonSet", func(ctx context.Context, ns string) ([]ownedObject, error) {
+			list, err := c.clientset.AppsV1().DaemonSets(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toOwnedObjects("DaemonSet", list.Items), nil
+		}},
+		{"ReplicaSet", func(ctx context.Context, ns string) ([]ownedObject, error) {
+			list, err := c.clientset.AppsV1().ReplicaSets(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toOwnedObjects("ReplicaSet", list.Items), nil
+		}},
+		{"Job", func(ctx context.Context, ns string) ([]ownedObject, error) {
+			list, err := c.clientset.BatchV1().Jobs(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toOwnedObjects("Job", list.Items), nil
+		}},
+		{"Pod", func(ctx context.Context, ns string) ([]ownedObject, error) {
+			list, err := c.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toOwnedObjects("Pod", list.Items), nil
+		}},
+		{"Service", func(ctx context.Context, ns string) ([]ownedObject, error) {
+			list, err := c.clientset.CoreV1().Services(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toOwnedObjects("Service", list.Items), nil
+		}},
+		{"Ingress", func(ctx context.Context, ns string) ([]ownedObject, error) {
+			list, err := c.clientset.NetworkingV1().Ingresses(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toOwnedObjects("Ingress", list.Items), nil
+		}},
+		{"ConfigMap", func(ctx context.Context, ns string) ([]ownedObject, error) {
+			list, err := c.clientset.CoreV1().ConfigMaps(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toOwnedObjects("ConfigMap", list.Items), nil
+		}},
+		{"PersistentVolumeClaim", func(ctx context.Context, ns string) ([]ownedObject, error) {
+			list, err := c.clientset.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toOwnedObjects("PersistentVolumeClaim", list.Items), nil
+		}},
+	}
+}
+
+// toOwnedObjects converts a list of typed Kubernetes objects
+func toOwnedObjects[T any, PT interface {
+	*T
+	metav1.Object
+}](kind string, items []T) []ownedObject {
+	objects := make([]ownedObject, 0, len(items))
+	for i := range items {
+		obj := PT(&items[i])
+		owned := ownedObject{
+			Kind:      kind,
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			UID:       obj.GetUID(),
+			Labels:    obj.GetLabels(),
+			Object:    obj,
+		}
+		for _, ref := range obj.GetOwnerReferences() {
+			owned.Owners = append(owned.Owners, ref.UID)
+		}
+		objects = append(objects, owned)
+	}
+	return objects
+}
+
+// key identifies an object within its namespace
+func (o ownedObject) key() string {
+	return o.Kind + "/" + o.Name
+}
+
+// applicationObjects returns the objects of an application: every object
+// carrying the application label, plus every object transitively owned by
+// one of them (e.g. the ReplicaSets and Pods of a labeled Deployment)
+func (c *Client) applicationObjects(ctx context.Context, app *Application) ([]ownedObject, error) {
+	// List every object in the namespace once
+	var all []ownedObject
+	for _, lister := range c.objectListers() {
+		objects, err := lister.list(ctx, app.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s objects: %w", lister.kind, err)
+		}
+		all = append(all, objects...)
+	}
+
+	// Start from the labeled objects
+	included := make(map[string]bool)
+	includedUIDs := make(map[types.UID]bool)
+	include := func(obj ownedObject) {
+		included[obj.key()] = true
+		if obj.UID != "" {
+			includedUIDs[obj.UID] = true
+		}
+	}
+	for _, obj := range all {
+		if obj.Labels[ApplicationLabel] == app.Name {
+			include(obj)
+		}
+	}
+
+	// Add owned objects until nothing changes
+	for changed := true; changed; {
+		changed = false
+		for _, obj := range all {
+			if included[obj.key()] {
+				continue
+			}
+			for _, owner := range obj.Owners {
+				if includedUIDs[owner] {
+					include(obj)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	var result []ownedObject
+	for _, obj := range all {
+		if included[obj.key()] {
+			result = append(result, obj)
+		}
+	}
+	return result, nil
+}
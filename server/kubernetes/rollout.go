@@ -0,0 +1,301 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sysintelligent/devops-bridge/server/tracing"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RolloutAction is an operation on the workloads of an application
+type RolloutAction string
+
+const (
+	// RolloutRestart restarts all pods of a workload
+	RolloutRestart RolloutAction = "restart"
+	// RolloutScale changes the number of replicas of a workload
+	RolloutScale RolloutAction = "scale"
+	// RolloutPause pauses the rollout of a Deployment
+	RolloutPause RolloutAction = "pause"
+	// RolloutResume resumes the rollout of a paused Deployment
+	RolloutResume RolloutAction = "resume"
+	// RolloutRollback rolls a workload back to a previous revision
+	RolloutRollback RolloutAction = "rollback"
+)
+
+// restartedAtAnnotation is the pod template annotation changed to trigger a
+// restart, the same one used by kubectl rollout restart
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// deploymentRevisionAnnotation holds the revision of a Deployment and its ReplicaSets
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+var (
+	// ErrInvalidRolloutAction is returned for unknown rollout actions
+	ErrInvalidRolloutAction = errors.New("invalid rollout action")
+	// ErrNoWorkloads is returned when an application has no matching Deployments or StatefulSets
+	ErrNoWorkloads = errors.New("application has no matching workloads")
+	// ErrNoRevision is returned when there is no revision to roll back to
+	ErrNoRevision = errors.New("no revision to roll back to")
+)
+
+// ParseRolloutAction parses and validates a rollout action
+func ParseRolloutAction(action string) (RolloutAction, error) {
+	switch a := RolloutAction(action); a {
+	case RolloutRestart, RolloutScale, RolloutPause, RolloutResume, RolloutRollback:
+		return a, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidRolloutAction, action)
+}
+
+// RolloutRequest describes a rollout action on an application
+type RolloutRequest struct {
+	// Action is the operation to perform
+	Action RolloutAction `json:"-"`
+	// Workload restricts the action to the workload with this name
+	Workload string `json:"workload,omitempty"`
+	// Replicas is the desired number of replicas, required for scale
+	Replicas *int32 `json:"replicas,omitempty"`
+	// ToRevision is the revision to roll back to; zero means the previous one
+	ToRevision int64 `json:"toRevision,omitempty"`
+}
+
+// WorkloadResult is the outcome of a rollout action on a single workload
+type WorkloadResult struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// RolloutApplication performs a rollout action on the Deployments and
+// StatefulSets carrying the application label
+func (c *Client) RolloutApplication(ctx context.Context, name string, req RolloutRequest) (_ []WorkloadResult, err error) {
+	ctx, span := startSpan(ctx, "RolloutApplication", name)
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := ParseRolloutAction(string(req.Action)); err != nil {
+		return nil, err
+	}
+	if req.Action == RolloutScale && (req.Replicas == nil || *req.Replicas < 0) {
+		return nil, fmt.Errorf("%w: scale requires a non-negative number of replicas", ErrInvalidRolloutAction)
+	}
+
+	app, err := c.GetApplication(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the workloads of the application
+	selector := metav1.ListOptions{LabelSelector: ApplicationLabel + "=" + app.Name}
+	deployments, err := c.clientset.AppsV1().Deployments(app.Namespace).List(ctx, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	statefulSets, err := c.clientset.AppsV1().StatefulSets(app.Namespace).List(ctx, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+
+	var results []WorkloadResult
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		if req.Workload != "" && req.Workload != d.Name {
+			continue
+		}
+		message, err := c.rolloutDeployment(ctx, d, req)
+		if err != nil {
+			return results, fmt.Errorf("deployment %s: %w", d.Name, err)
+		}
+		results = append(results, WorkloadResult{Kind: "Deployment", Name: d.Name, Message: message})
+	}
+	for i := range statefulSets.Items {
+		s := &statefulSets.Items[i]
+		if req.Workload != "" && req.Workload != s.Name {
+			continue
+		}
+		message, err := c.rolloutStatefulSet(ctx, s, req)
+		if err != nil {
+			return results, fmt.Errorf("statefulset %s: %w", s.Name, err)
+		}
+		results = append(results, WorkloadResult{Kind: "StatefulSet", Name: s.Name, Message: message})
+	}
+
+	if len(results) == 0 {
+		return nil, ErrNoWorkloads
+	}
+	return results, nil
+}
+
+// rolloutDeployment performs a rollout action on a Deployment
+func (c *Client) rolloutDeployment(ctx context.Context, d *appsv1.Deployment, req RolloutRequest) (string, error) {
+	deployments := c.clientset.AppsV1().Deployments(d.Namespace)
+
+	switch req.Action {
+	case RolloutRestart:
+		if _, err := deployments.Patch(ctx, d.Name, types.StrategicMergePatchType, restartPatch(), metav1.PatchOptions{}); err != nil {
+			return "", err
+		}
+		return "restarted", nil
+
+	case RolloutScale:
+		if _, err := deployments.Patch(ctx, d.Name, types.MergePatchType, scalePatch(*req.Replicas), metav1.PatchOptions{}); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("scaled to %d replicas", *req.Replicas), nil
+
+	case RolloutPause, RolloutResume:
+		paused := req.Action == RolloutPause
+		patch := []byte(fmt.Sprintf(`{"spec":{"paused":%t}}`, paused))
+		if _, err := deployments.Patch(ctx, d.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return "", err
+		}
+		if paused {
+			return "paused", nil
+		}
+		return "resumed", nil
+
+	case RolloutRollback:
+		return c.rollbackDeployment(ctx, d, req.ToRevision)
+	}
+	return "", ErrInvalidRolloutAction
+}
+
+// rolloutStatefulSet performs a rollout action on a StatefulSet
+func (c *Client) rolloutStatefulSet(ctx context.Context, s *appsv1.StatefulSet, req RolloutRequest) (string, error) {
+	statefulSets := c.clientset.AppsV1().StatefulSets(s.Namespace)
+
+	switch req.Action {
+	case RolloutRestart:
+		if _, err := statefulSets.Patch(ctx, s.Name, types.StrategicMergePatchType, restartPatch(), metav1.PatchOptions{}); err != nil {
+			return "", err
+		}
+		return "restarted", nil
+
+	case RolloutScale:
+		if _, err := statefulSets.Patch(ctx, s.Name, types.MergePatchType, scalePatch(*req.Replicas), metav1.PatchOptions{}); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("scaled to %d replicas", *req.Replicas), nil
+
+	case RolloutPause, RolloutResume:
+		// StatefulSets have no paused field
+		return "skipped: statefulsets cannot be paused", nil
+
+	case RolloutRollback:
+		return c.rollbackStatefulSet(ctx, s, req.ToRevision)
+	}
+	return "", ErrInvalidRolloutAction
+}
+
+// rollbackDeployment restores the pod template of a previous ReplicaSet,
+// like kubectl rollout undo
+func (c *Client) rollbackDeployment(ctx context.Context, d *appsv1.Deployment, toRevision int64) (string, error) {
+	replicaSets, err := c.clientset.AppsV1().ReplicaSets(d.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list replicasets: %w", err)
+	}
+
+	current, _ := strconv.ParseInt(d.Annotations[deploymentRevisionAnnotation], 10, 64)
+
+	// Find the target ReplicaSet among those owned by the Deployment
+	var target *appsv1.ReplicaSet
+	var targetRevision int64
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		if !ownedBy(rs.OwnerReferences, d.UID) {
+			continue
+		}
+		revision, err := strconv.ParseInt(rs.Annotations[deploymentRevisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch {
+		case toRevision > 0 && revision == toRevision:
+			target, targetRevision = rs, revision
+		case toRevision == 0 && revision < current && revision > targetRevision:
+			target, targetRevision = rs, revision
+		}
+	}
+	if target == nil {
+		return "", ErrNoRevision
+	}
+
+	// Restore the pod template, minus the label added by the controller
+	template := target.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	updated := d.DeepCopy()
+	updated.Spec.Template = *template
+	if _, err := c.clientset.AppsV1().Deployments(d.Namespace).Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("rolled back to revision %d", targetRevision), nil
+}
+
+// rollbackStatefulSet reapplies the template of a previous ControllerRevision,
+// like kubectl rollout undo
+func (c *Client) rollbackStatefulSet(ctx context.Context, s *appsv1.StatefulSet, toRevision int64) (string, error) {
+	list, err := c.clientset.AppsV1().ControllerRevisions(s.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list controllerrevisions: %w", err)
+	}
+
+	var revisions []*appsv1.ControllerRevision
+	var current int64
+	for i := range list.Items {
+		rev := &list.Items[i]
+		if !ownedBy(rev.OwnerReferences, s.UID) {
+			continue
+		}
+		revisions = append(revisions, rev)
+		if rev.Name == s.Status.UpdateRevision {
+			current = rev.Revision
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision > revisions[j].Revision })
+
+	// Find the requested revision, or the newest one before the current one
+	var target *appsv1.ControllerRevision
+	for _, rev := range revisions {
+		if (toRevision > 0 && rev.Revision == toRevision) || (toRevision == 0 && rev.Revision < current) {
+			target = rev
+			break
+		}
+	}
+	if target == nil {
+		return "", ErrNoRevision
+	}
+
+	// The revision data is a strategic merge patch of the pod template
+	if _, err := c.clientset.AppsV1().StatefulSets(s.Namespace).Patch(ctx, s.Name, types.StrategicMergePatchType, target.Data.Raw, metav1.PatchOptions{}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("rolled back to revision %d", target.Revision), nil
+}
+
+// restartPatch returns a strategic merge patch that restarts all pods of a workload
+func restartPatch() []byte {
+	return []byte(fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		restartedAtAnnotation, time.Now().Format(time.RFC3339)))
+}
+
+// scalePatch returns a merge patch that sets the number of replicas of a workload
+func scalePatch(replicas int32) []byte {
+	return []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+}
+
+// ownedBy reports whether the owner references contain uid
+func ownedBy(refs []metav1.OwnerReference, uid types.UID) bool {
+	for _, ref := range refs {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,465 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/sysintelligent/devops-bridge/server/tracing"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// fieldManager is the owner of the fields applied when syncing
+const fieldManager = "devops-bridge"
+
+// ErrNoManifestSource is returned when refreshing or syncing an application
+// that has a source while no ManifestSource is set
+var ErrNoManifestSource = errors.New("no manifest source configured")
+
+// ManifestSource renders the desired state of applications from their source
+type ManifestSource interface {
+	// Render returns the desired objects of app and the commit they were
+	// rendered from
+	Render(ctx context.Context, app *Application) (objects []*unstructured.Unstructured, commit string, err error)
+}
+
+// SourceChecker is implemented by manifest sources that can check the
+// source of an application when it is created or updated, rather than only
+// when it is refreshed
+type SourceChecker interface {
+	// CheckSource returns an error if the source of app cannot be rendered
+	CheckSource(app *Application) error
+}
+
+// ErrInvalidSource is returned when creating or updating an application
+// whose source the ManifestSource rejects
+var ErrInvalidSource = errors.New("invalid application source")
+
+// ErrNoSource is returned when rendering an application without a source
+var ErrNoSource = errors.New("application has no source")
+
+// Manifests are the desired objects of an application as rendered from its
+// source
+type Manifests struct {
+	// Commit is the commit of the source the objects were rendered from
+	Commit  string                       `json:"commit"`
+	Objects []*unstructured.Unstructured `json:"objects"`
+}
+
+// SetManifestSource sets the source that applications with a source are
+// rendered with
+func (c *Client) SetManifestSource(source ManifestSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.source = source
+}
+
+// checkSource checks the source of app with the manifest source, if it is a
+// SourceChecker. Template sources need one, as they have no repository to
+// fall back on. The caller must hold c.mu.
+func (c *Client) checkSource(app *Application) error {
+	if app.Source == nil {
+		return nil
+	}
+	checker, ok := c.source.(SourceChecker)
+	if !ok {
+		if app.Source.Template != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSource, ErrNoManifestSource)
+		}
+		return nil
+	}
+	if err := checker.CheckSource(app); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSource, err)
+	}
+	return nil
+}
+
+// GetApplicationManifests renders the desired objects of an application
+// from its source, as they are applied when syncing
+func (c *Client) GetApplicationManifests(ctx context.Context, name string) (_ *Manifests, err error) {
+	ctx, span := startSpan(ctx, "GetApplicationManifests", name)
+	defer func() { tracing.End(span, err) }()
+
+	app, err := c.GetApplication(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if app.Source == nil {
+		return nil, ErrNoSource
+	}
+
+	objects, commit, err := c.render(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	return &Manifests{Commit: commit, Objects: objects}, nil
+}
+
+// RefreshApplication compares an application with its desired state and
+// returns it with an up to date sync status
+func (c *Client) RefreshApplication(ctx context.Context, name string) (_ *Application, err error) {
+	ctx, span := startSpan(ctx, "RefreshApplication", name)
+	defer func() { tracing.End(span, err) }()
+
+	app, err := c.GetApplication(ctx, name)
+	if err != nil || app.Source == nil {
+		// Without a source, the sync status is the one last stored
+		return app, err
+	}
+
+	objects, commit, err := c.render(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	syncStatus := SyncStatusSynced
+	for _, desired := range objects {
+		live, err := c.liveObject(ctx, app, desired)
+		if apierrors.IsNotFound(err) {
+			syncStatus = SyncStatusOutOfSync
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s %s: %w", desired.GetKind(), desired.GetName(), err)
+		}
+		if !matches(desired.Object, live.Object) {
+			syncStatus = SyncStatusOutOfSync
+			break
+		}
+	}
+
+	// Objects that are no longer desired but still live need pruning
+	desired, err := c.objectKeys(app, objects)
+	if err != nil {
+		return nil, err
+	}
+	live, err := c.applicationObjects(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	if len(extraneous(app, live, desired)) > 0 {
+		syncStatus = SyncStatusOutOfSync
+	}
+	health := applicationHealth(app, live)
+
+	var previous ApplicationStatus
+	updated, err := c.updateSyncState(name, func(app *Application) {
+		previous = app.Status
+		app.Status = health
+		app.SyncStatus = syncStatus
+		app.TargetCommit = commit
+		if syncStatus == SyncStatusSynced {
+			app.SyncedCommit = commit
+		}
+	})
+	if err == nil && updated.Status != previous {
+		c.notifyStatusChange(*updated, previous)
+	}
+	return updated, err
+}
+
+// SyncApplication applies the desired state of an application in sync waves
+// and returns it as synced. With prune, objects that are no longer desired
+// are deleted. It fails with a *BlockedError while a dependency of the
+// application is not healthy.
+func (c *Client) SyncApplication(ctx context.Context, name string, prune bool) (_ *Application, err error) {
+	ctx, span := startSpan(ctx, "SyncApplication", name)
+	defer func() { tracing.End(span, err) }()
+
+	app, err := c.GetApplication(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkDependencies(app); err != nil {
+		return nil, err
+	}
+	if app.Source == nil {
+		// There are no objects to apply or prune, so syncing marks the
+		// application as synced
+		return c.updateSyncState(name, func(app *Application) {
+			app.SyncStatus = SyncStatusSynced
+		})
+	}
+
+	objects, commit, err := c.render(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	waves, err := syncWaves(objects)
+	if err != nil {
+		return nil, err
+	}
+	for i, wave := range waves {
+		for _, obj := range wave.objects {
+			if err := c.applyObject(ctx, app, obj); err != nil {
+				return nil, fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+			}
+		}
+		// The next wave may rely on this one, e.g. on a migration Job
+		if i < len(waves)-1 {
+			if err := c.waitForWave(ctx, app, wave); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if prune {
+		desired, err := c.objectKeys(app, objects)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.prune(ctx, app, desired); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.updateSyncState(name, func(app *Application) {
+		app.SyncStatus = SyncStatusSynced
+		app.TargetCommit = commit
+		app.SyncedCommit = commit
+	})
+}
+
+// render renders the desired objects of an application from its source
+func (c *Client) render(ctx context.Context, app *Application) ([]*unstructured.Unstructured, string, error) {
+	c.mu.RLock()
+	source := c.source
+	c.mu.RUnlock()
+	if source == nil {
+		return nil, "", ErrNoManifestSource
+	}
+
+	objects, commit, err := source.Render(ctx, app)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render manifests: %w", err)
+	}
+	// Label the objects so they show up in the resource tree and can be pruned
+	for _, obj := range objects {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[ApplicationLabel] = app.Name
+		obj.SetLabels(labels)
+	}
+	return objects, commit, nil
+}
+
+// updateSyncState changes the stored sync state of an application and
+// returns a copy of it
+func (c *Client) updateSyncState(name string, update func(app *Application)) (*Application, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	app, ok := c.applications[name]
+	if !ok {
+		return nil, ErrApplicationNotFound
+	}
+	update(app)
+
+	copied := *app
+	return &copied, nil
+}
+
+// resourceFor returns the dynamic client of the kind of obj, in the
+// namespace of obj or else of the application. It fills in the namespace of
+// namespaced objects that don't set one.
+func (c *Client) resourceFor(app *Application, obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// The kind may have been installed since discovery was cached
+		c.mapper.Reset()
+		mapping, err = c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		// A namespace set on a cluster-scoped object is ignored
+		obj.SetNamespace("")
+		return c.dynamic.Resource(mapping.Resource), nil
+	}
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(app.Namespace)
+	}
+	return c.dynamic.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+}
+
+// liveObject returns the live counterpart of a desired object
+func (c *Client) liveObject(ctx context.Context, app *Application, desired *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	resource, err := c.resourceFor(app, desired)
+	if err != nil {
+		return nil, err
+	}
+	return resource.Get(ctx, desired.GetName(), metav1.GetOptions{})
+}
+
+// applyObject creates or updates an object with server-side apply, taking
+// over fields changed by others
+func (c *Client) applyObject(ctx context.Context, app *Application, obj *unstructured.Unstructured) error {
+	resource, err := c.resourceFor(app, obj)
+	if err != nil {
+		return err
+	}
+	_, err = resource.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{FieldManager: fieldManager, Force: true})
+	return err
+}
+
+// objectKeys returns the keys of the desired objects of an application the
+// way extraneous compares them, filling in the namespace of namespaced
+// objects that don't set one like resourceFor does. Cluster-scoped objects
+// have no namespace.
+func (c *Client) objectKeys(app *Application, objects []*unstructured.Unstructured) (map[string]bool, error) {
+	keys := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		if _, err := c.resourceFor(app, obj); err != nil {
+			return nil, fmt.Errorf("failed to map %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		keys[objectKey(obj.GetKind(), obj.GetNamespace(), obj.GetName())] = true
+	}
+	return keys, nil
+}
+
+// objectKey identifies an object across namespaces
+func objectKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// extraneousObjects returns the objects of an application that are not
+// desired. Only objects carrying the application label themselves are
+// returned, not those they own, which their owner cleans up.
+func (c *Client) extraneousObjects(ctx context.Context, app *Application, desired map[string]bool) ([]ownedObject, error) {
+	objects, err := c.applicationObjects(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	return extraneous(app, objects, desired), nil
+}
+
+// extraneous returns the objects of an application, as returned by
+// applicationObjects, that are not desired
+func extraneous(app *Application, objects []ownedObject, desired map[string]bool) []ownedObject {
+	var result []ownedObject
+	for _, obj := range objects {
+		if obj.Labels[ApplicationLabel] != app.Name || len(obj.Owners) > 0 {
+			continue
+		}
+		if !desired[objectKey(obj.Kind, obj.Namespace, obj.Name)] {
+			result = append(result, obj)
+		}
+	}
+	return result
+}
+
+// prune deletes the objects of an application that are not desired
+func (c *Client) prune(ctx context.Context, app *Application, desired map[string]bool) error {
+	objects, err := c.extraneousObjects(ctx, app, desired)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		typed, ok := obj.Object.(runtime.Object)
+		if !ok {
+			continue
+		}
+		gvks, _, err := scheme.Scheme.ObjectKinds(typed)
+		if err != nil || len(gvks) == 0 {
+			return fmt.Errorf("failed to get kind of %s %s: %w", obj.Kind, obj.Name, err)
+		}
+		target := &unstructured.Unstructured{}
+		target.SetGroupVersionKind(gvks[0])
+		target.SetNamespace(obj.Namespace)
+		resource, err := c.resourceFor(app, target)
+		if err != nil {
+			return err
+		}
+
+		propagation := metav1.DeletePropagationForeground
+		err = resource.Delete(ctx, obj.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to prune %s %s: %w", obj.Kind, obj.Name, err)
+		}
+		c.logger.Info("Pruned object", "application", app.Name, "kind", obj.Kind, "name", obj.Name)
+	}
+	return nil
+}
+
+// matches reports whether every field set in desired has the same value in
+// live. Fields only in live, such as defaults and status, are ignored, and of
+// the metadata only labels and annotations are compared.
+func matches(desired, live map[string]interface{}) bool {
+	for key, value := range desired {
+		if key == "metadata" {
+			desiredMeta, _ := value.(map[string]interface{})
+			liveMeta, _ := live[key].(map[string]interface{})
+			for _, field := range []string{"labels", "annotations"} {
+				if _, ok := desiredMeta[field]; ok && !subset(desiredMeta[field], liveMeta[field]) {
+					return false
+				}
+			}
+			continue
+		}
+		if key == "status" {
+			continue
+		}
+		if !subset(value, live[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// subset reports whether desired is contained in live: maps may have more
+// keys in live, lists must have the same length, and other values must be
+// equal
+func subset(desired, live interface{}) bool {
+	switch desired := desired.(type) {
+	case map[string]interface{}:
+		liveMap, _ := live.(map[string]interface{})
+		for key, value := range desired {
+			if !subset(value, liveMap[key]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		liveList, _ := live.([]interface{})
+		if len(desired) != len(liveList) {
+			return false
+		}
+		for i := range desired {
+			if !subset(desired[i], liveList[i]) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return true
+	}
+	if desired, ok := toFloat(desired); ok {
+		live, ok := toFloat(live)
+		return ok && desired == live
+	}
+	return reflect.DeepEqual(desired, live)
+}
+
+// toFloat converts a JSON number, decoded as int64 or float64, to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
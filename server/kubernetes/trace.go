@@ -0,0 +1,15 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/sysintelligent/devops-bridge/server/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan starts a span for a Client call on an application. Requests to
+// the API server made during the call become child spans.
+func startSpan(ctx context.Context, method, application string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "kubernetes.Client."+method, attribute.String("application", application))
+}
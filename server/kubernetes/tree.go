@@ -0,0 +1,108 @@
+package kubernetes
+
+import (
+	"context"
+	"sort"
+
+	"github.com/sysintelligent/devops-bridge/server/tracing"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ResourceNode is a Kubernetes object in an application's resource tree
+type ResourceNode struct {
+	Kind          string            `json:"kind"`
+	Name          string            `json:"name"`
+	Namespace     string            `json:"namespace"`
+	Health        ApplicationStatus `json:"health"`
+	HealthMessage string            `json:"healthMessage,omitempty"`
+	// SyncStatus is only set on objects managed by the application itself,
+	// not on objects created by controllers such as ReplicaSets and Pods
+	SyncStatus SyncStatus      `json:"syncStatus,omitempty"`
+	Children   []*ResourceNode `json:"children,omitempty"`
+}
+
+// kindOrder orders sibling nodes so workloads come before networking,
+// configuration and storage
+var kindOrder = map[string]int{
+	"Deployment":            0,
+	"StatefulSet":           1,
+	"DaemonSet":             2,
+	"Job":                   3,
+	"ReplicaSet":            4,
+	"Pod":                   5,
+	"Service":               6,
+	"Ingress":               7,
+	"ConfigMap":             8,
+	"PersistentVolumeClaim": 9,
+}
+
+// GetApplicationResources returns the tree of Kubernetes objects that make up
+// an application. Roots are the objects carrying the application label; their
+// children are the objects they own through ownerReferences.
+func (c *Client) GetApplicationResources(ctx context.Context, name string) (_ []*ResourceNode, err error) {
+	ctx, span := startSpan(ctx, "GetApplicationResources", name)
+	defer func() { tracing.End(span, err) }()
+
+	app, err := c.GetApplication(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := c.applicationObjects(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a node per object
+	nodes := make(map[types.UID]*ResourceNode)
+	var roots []*ResourceNode
+	created := make([]*ResourceNode, len(objects))
+	for i, obj := range objects {
+		health, message := objectHealth(obj.Object)
+		node := &ResourceNode{
+			Kind:          obj.Kind,
+			Name:          obj.Name,
+			Namespace:     app.Namespace,
+			Health:        health,
+			HealthMessage: message,
+		}
+		if obj.Labels[ApplicationLabel] == app.Name {
+			node.SyncStatus = app.SyncStatus
+		}
+		created[i] = node
+		if obj.UID != "" {
+			nodes[obj.UID] = node
+		}
+	}
+
+	// Attach every node to its first owner within the application
+	for i, obj := range objects {
+		var parent *ResourceNode
+		for _, owner := range obj.Owners {
+			if parent = nodes[owner]; parent != nil {
+				break
+			}
+		}
+		if parent != nil {
+			parent.Children = append(parent.Children, created[i])
+		} else {
+			roots = append(roots, created[i])
+		}
+	}
+
+	sortNodes(roots)
+	return roots, nil
+}
+
+// sortNodes sorts a tree level by kind and name, recursively
+func sortNodes(nodes []*ResourceNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Kind != nodes[j].Kind {
+			return kindOrder[nodes[i].Kind] < kindOrder[nodes[j].Kind]
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+	for _, node := range nodes {
+		sortNodes(node.Children)
+	}
+}
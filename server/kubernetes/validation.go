@@ -0,0 +1,183 @@
+package kubernetes
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// FieldError describes a single invalid field of an application
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. source.repoURL
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when creating or updating an invalid
+// application. It lists every invalid field.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		problems[i] = field.Field + ": " + field.Message
+	}
+	return "invalid application: " + strings.Join(problems, "; ")
+}
+
+// Validate checks the fields of an application given by a client. Fields
+// owned by the server are not checked, as they are ignored on input.
+func (a *Application) Validate() error {
+	var fields []FieldError
+	invalid := func(field, format string, args ...interface{}) {
+		fields = append(fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	dnsLabel := func(field, value string) {
+		if value == "" {
+			invalid(field, "must not be empty")
+			return
+		}
+		// Application names are also label values, so both are DNS-1123 labels
+		for _, msg := range validation.IsDNS1123Label(value) {
+			invalid(field, "%s", msg)
+		}
+	}
+
+	dnsLabel("name", a.Name)
+	dnsLabel("namespace", a.Namespace)
+	for _, key := range sortedKeys(a.Labels) {
+		for _, msg := range validation.IsQualifiedName(key) {
+			invalid("labels", "%s: %s", key, msg)
+		}
+		for _, msg := range validation.IsValidLabelValue(a.Labels[key]) {
+			invalid("labels", "%s: %s", key, msg)
+		}
+	}
+
+	seen := make(map[string]bool, len(a.DependsOn))
+	for i, dep := range a.DependsOn {
+		field := fmt.Sprintf("dependsOn[%d]", i)
+		switch {
+		case dep == a.Name:
+			invalid(field, "an application cannot depend on itself")
+		case seen[dep]:
+			invalid(field, "duplicate dependency %s", dep)
+		default:
+			dnsLabel(field, dep)
+		}
+		seen[dep] = true
+	}
+
+	if !a.SyncPolicy.AutoSync {
+		if a.SyncPolicy.SelfHeal {
+			invalid("syncPolicy.selfHeal", "requires autoSync")
+		}
+		if a.SyncPolicy.Prune {
+			invalid("syncPolicy.prune", "requires autoSync")
+		}
+	}
+
+	if source := a.Source; source != nil {
+		if source.Template != nil {
+			if source.RepoURL != "" || source.Revision != "" || source.Path != "" || source.Helm != nil || source.Kustomize != nil {
+				invalid("source.template", "cannot be combined with a repository")
+			}
+			if source.Template.Name == "" {
+				invalid("source.template.name", "must not be empty")
+			}
+		} else if source.RepoURL == "" {
+			invalid("source.repoURL", "must not be empty")
+		} else if !IsRemoteRepoURL(source.RepoURL) {
+			invalid("source.repoURL", "must be a remote http(s), ssh or git URL")
+		}
+		if source.Path != "" && !filepath.IsLocal(source.Path) {
+			invalid("source.path", "must be relative and stay within the repository")
+		}
+		if source.Helm != nil && source.Kustomize != nil {
+			invalid("source.kustomize", "cannot be combined with helm")
+		}
+		if helm := source.Helm; helm != nil {
+			for i, file := range helm.ValueFiles {
+				if !filepath.IsLocal(file) {
+					invalid(fmt.Sprintf("source.helm.valueFiles[%d]", i), "must be relative and stay within the repository")
+				}
+			}
+		}
+		if kustomize := source.Kustomize; kustomize != nil {
+			if kustomize.NamePrefix != "" {
+				for _, msg := range validation.IsDNS1123Subdomain(strings.TrimSuffix(kustomize.NamePrefix, "-")) {
+					invalid("source.kustomize.namePrefix", "%s", msg)
+				}
+			}
+			for _, key := range sortedKeys(kustomize.CommonLabels) {
+				value := kustomize.CommonLabels[key]
+				for _, msg := range validation.IsQualifiedName(key) {
+					invalid("source.kustomize.commonLabels", "%s: %s", key, msg)
+				}
+				for _, msg := range validation.IsValidLabelValue(value) {
+					invalid("source.kustomize.commonLabels", "%s: %s", key, msg)
+				}
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// ApplicationStatuses are the health statuses an application may have
+var ApplicationStatuses = []ApplicationStatus{
+	ApplicationStatusHealthy,
+	ApplicationStatusProgressing,
+	ApplicationStatusSuspended,
+	ApplicationStatusDegraded,
+	ApplicationStatusUnknown,
+}
+
+// remoteRepoSchemes are the URL schemes of Git repositories on a server
+var remoteRepoSchemes = []string{"https", "http", "ssh", "git", "git+ssh", "ssh+git"}
+
+// IsRemoteRepoURL reports whether repoURL points at a Git repository on a
+// server, by URL or in the scp-like user@host:path form. Repositories on the
+// server's own file system, file:// URLs and remote helpers such as ext::
+// are not, as they would let clients read or run anything on the server.
+func IsRemoteRepoURL(repoURL string) bool {
+	if strings.HasPrefix(repoURL, "-") || strings.Contains(repoURL, "::") {
+		return false
+	}
+	if strings.Contains(repoURL, "://") {
+		u, err := url.Parse(repoURL)
+		return err == nil && slices.Contains(remoteRepoSchemes, u.Scheme) && u.Hostname() != ""
+	}
+	// Git reads host:path as scp-like only without a slash before the colon,
+	// and anything else as a local path
+	host, path, ok := strings.Cut(repoURL, ":")
+	if !ok || strings.Contains(host, "/") || path == "" {
+		return false
+	}
+	if _, hostname, ok := strings.Cut(host, "@"); ok {
+		host = hostname
+	}
+	return host != ""
+}
+
+// sortedKeys returns the keys of labels in order, so that problems are
+// reported in a stable order
+func sortedKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,118 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// SyncWaveAnnotation orders the objects of an application when syncing.
+// Objects are applied in waves of ascending value, the default being 0, and
+// each wave only once the objects of the previous one are healthy.
+const SyncWaveAnnotation = "devops-bridge.io/sync-wave"
+
+// WavePollInterval is how often the health of a sync wave is checked while
+// waiting for it, be it the objects of an application or the applications
+// of a dependency wave
+var WavePollInterval = 2 * time.Second
+
+// waveTimeout bounds how long a sync waits for one of its sync waves to
+// become healthy, whatever time is left to the sync as a whole
+const waveTimeout = 10 * time.Minute
+
+// syncWave is the objects of an application applied together
+type syncWave struct {
+	number  int
+	objects []*unstructured.Unstructured
+}
+
+// syncWaves groups objects by their sync wave, lowest first, keeping the
+// order of the objects within a wave
+func syncWaves(objects []*unstructured.Unstructured) ([]syncWave, error) {
+	byNumber := make(map[int][]*unstructured.Unstructured)
+	for _, obj := range objects {
+		number := 0
+		if value, ok := obj.GetAnnotations()[SyncWaveAnnotation]; ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s annotation %q on %s %s", SyncWaveAnnotation, value, obj.GetKind(), obj.GetName())
+			}
+			number = n
+		}
+		byNumber[number] = append(byNumber[number], obj)
+	}
+
+	waves := make([]syncWave, 0, len(byNumber))
+	for number, objects := range byNumber {
+		waves = append(waves, syncWave{number: number, objects: objects})
+	}
+	sort.Slice(waves, func(i, j int) bool { return waves[i].number < waves[j].number })
+	return waves, nil
+}
+
+// waitForWave waits until the live objects of a wave are no longer
+// progressing. It fails if one of them is degraded, or if ctx is done or
+// waveTimeout passes first.
+func (c *Client) waitForWave(ctx context.Context, app *Application, wave syncWave) error {
+	ctx, cancel := context.WithTimeout(ctx, waveTimeout)
+	defer cancel()
+	ticker := time.NewTicker(WavePollInterval)
+	defer ticker.Stop()
+
+	for {
+		status, reason, err := c.waveHealth(ctx, app, wave)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case ApplicationStatusDegraded:
+			return fmt.Errorf("sync wave %d is degraded: %s", wave.number, reason)
+		case ApplicationStatusHealthy, ApplicationStatusSuspended:
+			return nil
+		}
+
+		c.logger.DebugContext(ctx, "Waiting for sync wave", "application", app.Name, "wave", wave.number, "reason", reason)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("sync wave %d did not become healthy: %s: %w", wave.number, reason, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// waveHealth assesses the live objects of a wave and returns the health of
+// the least healthy one, along with what it is waiting for
+func (c *Client) waveHealth(ctx context.Context, app *Application, wave syncWave) (ApplicationStatus, string, error) {
+	health, reason := ApplicationStatusHealthy, ""
+	for _, desired := range wave.objects {
+		live, err := c.liveObject(ctx, app, desired)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get %s %s: %w", desired.GetKind(), desired.GetName(), err)
+		}
+		status, message := liveHealth(live)
+		if healthOrder[status] > healthOrder[health] {
+			health, reason = status, fmt.Sprintf("%s %s: %s", live.GetKind(), live.GetName(), message)
+		}
+	}
+	return health, reason, nil
+}
+
+// liveHealth assesses the health of a live object, converting it to its
+// typed form for the kinds objectHealth knows
+func liveHealth(live *unstructured.Unstructured) (ApplicationStatus, string) {
+	typed, err := scheme.Scheme.New(live.GroupVersionKind())
+	if err != nil {
+		// Not a built-in kind, so healthy once it exists
+		return ApplicationStatusHealthy, ""
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(live.Object, typed); err != nil {
+		return ApplicationStatusUnknown, err.Error()
+	}
+	return objectHealth(typed)
+}
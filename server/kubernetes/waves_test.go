@@ -0,0 +1,80 @@
+package kubernetes
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// object creates a ConfigMap in the given sync wave, or without the
+// annotation if wave is empty
+func object(name, wave string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetName(name)
+	if wave != "" {
+		obj.SetAnnotations(map[string]string{SyncWaveAnnotation: wave})
+	}
+	return obj
+}
+
+func TestSyncWaves(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		objects []*unstructured.Unstructured
+		// want lists the object names of each wave by wave number
+		want    map[int][]string
+		order   []int
+		wantErr bool
+	}{
+		{name: "no objects", order: []int{}},
+		{
+			name:    "default wave",
+			objects: []*unstructured.Unstructured{object("a", ""), object("b", "0")},
+			want:    map[int][]string{0: {"a", "b"}},
+			order:   []int{0},
+		},
+		{
+			name: "ordered by number, keeping the order within a wave",
+			objects: []*unstructured.Unstructured{
+				object("deployment", ""), object("migration", "-1"), object("smoke-test", "5"), object("service", ""), object("crd", "-5"),
+			},
+			want:  map[int][]string{-5: {"crd"}, -1: {"migration"}, 0: {"deployment", "service"}, 5: {"smoke-test"}},
+			order: []int{-5, -1, 0, 5},
+		},
+		{
+			name:    "invalid annotation",
+			objects: []*unstructured.Unstructured{object("a", "first")},
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			waves, err := syncWaves(tt.objects)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("syncWaves() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("syncWaves() error = %v", err)
+			}
+			order := []int{}
+			got := make(map[int][]string)
+			for _, wave := range waves {
+				order = append(order, wave.number)
+				for _, obj := range wave.objects {
+					got[wave.number] = append(got[wave.number], obj.GetName())
+				}
+			}
+			if !reflect.DeepEqual(order, tt.order) {
+				t.Errorf("syncWaves() wave numbers = %v, want %v", order, tt.order)
+			}
+			if len(tt.want) > 0 && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("syncWaves() objects = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Timing of the leader election, the defaults of Kubernetes controllers
+const (
+	// LeaseDuration is how long other replicas wait before taking over a
+	// lease that was not renewed
+	LeaseDuration = 15 * time.Second
+	// RenewDeadline is how long the leader retries renewing its lease
+	// before giving up leadership
+	RenewDeadline = 10 * time.Second
+	// RetryPeriod is the interval between attempts to acquire or renew the lease
+	RetryPeriod = 2 * time.Second
+)
+
+// Options configures leader election
+type Options struct {
+	// Enabled turns on leader election. When disabled, this replica is
+	// always the leader.
+	Enabled bool
+	// Namespace and LeaseName identify the Lease object used as the lock
+	Namespace string
+	LeaseName string
+	// Identity is the unique name of this replica, usually the pod name
+	Identity string
+	// LeaseDuration, RenewDeadline and RetryPeriod tune the election, the
+	// package defaults if zero
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// Controller is a background task that runs until ctx is done
+type Controller func(ctx context.Context)
+
+// namedController is a registered controller
+type namedController struct {
+	name string
+	run  Controller
+}
+
+// Elector runs the background controllers of the server on the replica
+// that holds a Lease, while every replica keeps serving the API
+type Elector struct {
+	clientset kubernetes.Interface
+	opts      Options
+	logger    *slog.Logger
+	watchDog  *leaderelection.HealthzAdaptor
+
+	mu          sync.RWMutex
+	controllers []namedController
+	leader      string
+}
+
+// namespaceFile holds the namespace of the pod when running in a cluster
+const namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// NewElector creates an elector that uses clientset, which may be a fake
+// clientset in tests, to hold the lease. The namespace defaults to the one
+// the server runs in and the identity to the host name, which is the pod
+// name in a cluster.
+func NewElector(clientset kubernetes.Interface, opts Options, logger *slog.Logger) (*Elector, error) {
+	if opts.Namespace == "" {
+		opts.Namespace = "default"
+		if data, err := os.ReadFile(namespaceFile); err == nil {
+			opts.Namespace = strings.TrimSpace(string(data))
+		}
+	}
+	if opts.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get host name for leader election identity: %w", err)
+		}
+		opts.Identity = hostname
+	}
+	if opts.LeaseDuration == 0 {
+		opts.LeaseDuration = LeaseDuration
+	}
+	if opts.RenewDeadline == 0 {
+		opts.RenewDeadline = RenewDeadline
+	}
+	if opts.RetryPeriod == 0 {
+		opts.RetryPeriod = RetryPeriod
+	}
+
+	return &Elector{
+		clientset: clientset,
+		opts:      opts,
+		logger:    logger.With("identity", opts.Identity),
+		// Report unhealthy once the lease has gone unrenewed for this long
+		// past its expiry
+		watchDog: leaderelection.NewLeaderHealthzAdaptor(opts.RenewDeadline),
+	}, nil
+}
+
+// AddController registers a controller to run while this replica is the
+// leader. Controllers must be added before Run is called.
+func (e *Elector) AddController(name string, run Controller) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.controllers = append(e.controllers, namedController{name: name, run: run})
+}
+
+// Run takes part in the leader election until ctx is done, running the
+// controllers whenever this replica is the leader. Leadership is released
+// when ctx is done so another replica can take over at once. A replica that
+// loses leadership waits for its controllers to stop before it rejoins the
+// election, so they never run twice on one replica.
+func (e *Elector) Run(ctx context.Context) error {
+	if !e.opts.Enabled {
+		e.setLeader(e.opts.Identity)
+		e.runControllers(ctx)
+		return nil
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: e.opts.Namespace,
+			Name:      e.opts.LeaseName,
+		},
+		Client:     e.clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: e.opts.Identity},
+	}
+
+	for ctx.Err() == nil {
+		// The elector starts leading in a goroutine of its own; hand the
+		// term over so the controllers run here and are waited for
+		started := make(chan context.Context, 1)
+		elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			Name:            e.opts.LeaseName,
+			LeaseDuration:   e.opts.LeaseDuration,
+			RenewDeadline:   e.opts.RenewDeadline,
+			RetryPeriod:     e.opts.RetryPeriod,
+			ReleaseOnCancel: true,
+			WatchDog:        e.watchDog,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					started <- ctx
+				},
+				OnStoppedLeading: func() {
+					e.logger.Info("Stopped leading")
+					e.setLeader("")
+				},
+				OnNewLeader: func(identity string) {
+					e.logger.Info("Leader elected", "leader", identity)
+					e.setLeader(identity)
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create leader elector: %w", err)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			elector.Run(ctx)
+		}()
+		select {
+		case termCtx := <-started:
+			// The elector cancels termCtx once it stops renewing the lease
+			e.logger.Info("Started leading, running controllers")
+			e.runControllers(termCtx)
+			e.logger.Info("Controllers stopped")
+			<-done
+		case <-done:
+			// ctx was done before the lease was acquired
+		}
+	}
+	return nil
+}
+
+// runControllers runs every controller until ctx is done
+func (e *Elector) runControllers(ctx context.Context) {
+	e.mu.RLock()
+	controllers := e.controllers
+	e.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, controller := range controllers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.logger.Debug("Starting controller", "controller", controller.name)
+			controller.run(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// setLeader records the identity of the current leader
+func (e *Elector) setLeader(identity string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.leader = identity
+}
+
+// Leader returns the identity of the current leader, if known
+func (e *Elector) Leader() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader
+}
+
+// IsLeader reports whether this replica is the leader
+func (e *Elector) IsLeader() bool {
+	return e.Leader() == e.opts.Identity
+}
+
+// Check fails if this replica holds the lease but has not been able to renew
+// it, which means it may be running controllers alongside a new leader. It
+// does not fail on replicas that are not the leader. It is a liveness check,
+// as serving the API does not depend on the lease.
+func (e *Elector) Check(ctx context.Context) error {
+	return e.watchDog.Check(nil)
+}
+
+// Status describes the leadership of this replica
+func (e *Elector) Status() string {
+	switch leader := e.Leader(); {
+	case !e.opts.Enabled:
+		return "disabled, this replica runs the controllers"
+	case leader == "":
+		return "no leader elected yet"
+	case leader == e.opts.Identity:
+		return "this replica is the leader"
+	default:
+		return fmt.Sprintf("%s is the leader", leader)
+	}
+}
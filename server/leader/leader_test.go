@@ -0,0 +1,149 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// events records what the controllers of several replicas did, in order
+type events struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (e *events) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.list = append(e.list, event)
+}
+
+func (e *events) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.list...)
+}
+
+// newTestElector creates an elector with short timings on clientset, whose
+// controller records its start and stop in events. The controller takes
+// stopDelay to stop once its context is done.
+func newTestElector(t *testing.T, clientset *fake.Clientset, identity string, ev *events, stopDelay time.Duration) *Elector {
+	t.Helper()
+	e, err := NewElector(clientset, Options{
+		Enabled:       true,
+		Namespace:     "default",
+		LeaseName:     "test",
+		Identity:      identity,
+		LeaseDuration: time.Second,
+		RenewDeadline: 500 * time.Millisecond,
+		RetryPeriod:   100 * time.Millisecond,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.AddController("test", func(ctx context.Context) {
+		ev.add("start " + identity)
+		<-ctx.Done()
+		time.Sleep(stopDelay)
+		ev.add("stop " + identity)
+	})
+	return e
+}
+
+// waitFor polls until cond holds or fails the test after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLeaseHandover(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	ev := &events{}
+	a := newTestElector(t, clientset, "a", ev, 0)
+	b := newTestElector(t, clientset, "b", ev, 0)
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	doneA := make(chan error, 1)
+	go func() { doneA <- a.Run(ctxA) }()
+	waitFor(t, "a to lead", a.IsLeader)
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	doneB := make(chan error, 1)
+	go func() { doneB <- b.Run(ctxB) }()
+	waitFor(t, "b to see a as leader", func() bool { return b.Leader() == "a" })
+	if b.IsLeader() {
+		t.Fatal("b leads while a holds the lease")
+	}
+
+	// a releases the lease when stopped, so b takes over without waiting
+	// for it to expire
+	cancelA()
+	if err := <-doneA; err != nil {
+		t.Fatalf("a.Run() = %v", err)
+	}
+	waitFor(t, "b to lead", b.IsLeader)
+
+	cancelB()
+	if err := <-doneB; err != nil {
+		t.Fatalf("b.Run() = %v", err)
+	}
+	want := []string{"start a", "stop a", "start b", "stop b"}
+	if got := ev.get(); !slices.Equal(got, want) {
+		t.Errorf("controller events = %v, want %v", got, want)
+	}
+}
+
+func TestLostLeaseStopsControllersBeforeRejoining(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	ev := &events{}
+	// The controller is slow to stop, so rejoining without waiting for it
+	// would start the next term's controller first
+	a := newTestElector(t, clientset, "a", ev, 300*time.Millisecond)
+
+	// Renewals fail while failing is set. Reactors can't be added while the
+	// clientset is in use.
+	var failing atomic.Bool
+	clientset.PrependReactor("update", "leases", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if failing.Load() {
+			return true, nil, errors.New("API server unavailable")
+		}
+		return false, nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+	waitFor(t, "a to lead", a.IsLeader)
+
+	// Fail renewals for longer than the renew deadline so a loses the lease
+	failing.Store(true)
+	waitFor(t, "a to stop leading", func() bool { return !a.IsLeader() })
+	failing.Store(false)
+
+	waitFor(t, "a to lead again", func() bool { return len(ev.get()) >= 3 })
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	want := []string{"start a", "stop a", "start a", "stop a"}
+	if got := ev.get(); !slices.Equal(got, want) {
+		t.Errorf("controller events = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCInterceptor creates a gRPC interceptor that assigns a request ID,
+// taken from the x-request-id metadata or generated, returns it in the
+// response header and writes an access log entry for every call. It must
+// come before auth.GRPCAuthInterceptor so rejected calls are logged too.
+func GRPCInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	key := strings.ToLower(RequestIDHeader)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		var requestID string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(key); len(values) > 0 {
+				requestID = values[0]
+			}
+		}
+		requestID = RequestIDOrNew(requestID)
+		ctx = NewContext(ctx, requestID)
+		grpc.SetHeader(ctx, metadata.Pairs(key, requestID))
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		switch code {
+		case codes.OK, codes.NotFound, codes.AlreadyExists, codes.InvalidArgument,
+			codes.FailedPrecondition, codes.Unauthenticated, codes.PermissionDenied, codes.Canceled:
+		default:
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+		}
+		logger.LogAttrs(ctx, level, "gRPC request", attrs...)
+
+		return resp, err
+	}
+}
@@ -0,0 +1,137 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is the HTTP header, and lowercased the gRPC metadata key,
+// carrying the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength caps the length of request IDs supplied by callers
+const maxRequestIDLength = 128
+
+// Output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New creates a logger writing to w in format (text or json) that drops
+// records below level (debug, info, warn or error). Records logged with a
+// context include the attributes added to it with AddAttrs and the trace ID.
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q", level)
+		}
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+// Discard returns a logger that drops all records, used when no logger is configured
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// contextKey is the type of the context key holding request attributes
+type contextKey struct{}
+
+// attrs holds the attributes of a request. It is shared by every context
+// derived from the request's, so attributes added by inner handlers, e.g. the
+// user ID once authenticated, also show up in the access log.
+type attrs struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// NewContext returns a context for a request with the given ID
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &attrs{
+		attrs: []slog.Attr{slog.String("request_id", requestID)},
+	})
+}
+
+// AddAttrs adds attributes to every record logged with the request context.
+// It does nothing if ctx was not created by NewContext.
+func AddAttrs(ctx context.Context, add ...slog.Attr) {
+	a, ok := ctx.Value(contextKey{}).(*attrs)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	a.attrs = append(a.attrs, add...)
+	a.mu.Unlock()
+}
+
+// RequestID returns the ID of the request of ctx, if any
+func RequestID(ctx context.Context) string {
+	a, ok := ctx.Value(contextKey{}).(*attrs)
+	if !ok {
+		return ""
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.attrs[0].Value.String()
+}
+
+// RequestIDOrNew returns id if it is a usable request ID supplied by a
+// caller, or a new random ID otherwise
+func RequestIDOrNew(id string) string {
+	if id != "" && len(id) <= maxRequestIDLength && !strings.ContainsAny(id, "\r\n") {
+		return id
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// contextHandler adds the request attributes and trace ID of the context to records
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implements slog.Handler
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if a, ok := ctx.Value(contextKey{}).(*attrs); ok {
+		a.mu.Lock()
+		r.AddAttrs(a.attrs...)
+		a.mu.Unlock()
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -2,76 +2,259 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"github.com/spf13/pflag"
 	"github.com/sysintelligent/devops-bridge/server/api"
+	"github.com/sysintelligent/devops-bridge/server/audit"
 	"github.com/sysintelligent/devops-bridge/server/auth"
+	"github.com/sysintelligent/devops-bridge/server/autosync"
+	"github.com/sysintelligent/devops-bridge/server/batch"
+	"github.com/sysintelligent/devops-bridge/server/catalog"
+	"github.com/sysintelligent/devops-bridge/server/certs"
+	"github.com/sysintelligent/devops-bridge/server/config"
+	"github.com/sysintelligent/devops-bridge/server/grpcweb"
+	"github.com/sysintelligent/devops-bridge/server/health"
+	"github.com/sysintelligent/devops-bridge/server/incidents"
 	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"github.com/sysintelligent/devops-bridge/server/leader"
+	"github.com/sysintelligent/devops-bridge/server/logging"
+	"github.com/sysintelligent/devops-bridge/server/metrics"
+	"github.com/sysintelligent/devops-bridge/server/revisions"
+	"github.com/sysintelligent/devops-bridge/server/sources"
+	"github.com/sysintelligent/devops-bridge/server/tracing"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const (
-	httpPort = 8080
-	grpcPort = 9090
+	// auditCapacity is the number of audit entries kept in memory for queries
+	auditCapacity = 10000
 )
 
 func main() {
+	// Load configuration
+	cfg, printConfig, err := config.Load(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			os.Exit(0)
+		}
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		os.Exit(2)
+	}
+	if printConfig {
+		cfg.Print(os.Stdout)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if printConfig {
+		return
+	}
+
 	// Set up logger
-	logger := log.New(os.Stdout, "DEVOPS-SERVER: ", log.LstdFlags|log.Lshortfile)
-	logger.Println("Starting DevOps Bridge server...")
+	logger, err := logging.New(os.Stdout, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up logging: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+	logger.Info("Starting DevOps Bridge server...")
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter)
+	if err != nil {
+		fatal(logger, "Failed to set up tracing", err)
+	}
+	logger.Info("Tracing initialized")
+
+	// Initialize metrics before the Kubernetes client so its requests are observed
+	serverMetrics := metrics.New()
 
 	// Initialize Kubernetes client
-	k8sClient, err := kubernetes.NewClient()
+	k8sClient, err := kubernetes.NewClient(logger, cfg.Kubernetes.Kubeconfig, cfg.Kubernetes.Context)
 	if err != nil {
-		logger.Fatalf("Failed to create Kubernetes client: %v", err)
+		fatal(logger, "Failed to create Kubernetes client", err)
 	}
-	logger.Println("Kubernetes client initialized")
+	logger.Info("Kubernetes client initialized")
+
+	// Render applications with a source from their Git repository or from
+	// a catalog template
+	templateCatalog := catalog.New()
+	k8sClient.SetManifestSource(sources.NewRenderer(cfg.Sources.CacheDir, templateCatalog))
 
 	// Initialize auth service
-	authService := auth.NewService()
-	logger.Println("Auth service initialized")
+	authService := auth.NewService(logger, auth.Options{
+		Disabled:   cfg.Auth.Mode == config.AuthModeNone,
+		UserToken:  cfg.Auth.UserToken,
+		AdminToken: cfg.Auth.AdminToken,
+	})
+	if cfg.Auth.Mode == config.AuthModeNone {
+		logger.Warn("Authentication is disabled, every caller is an admin")
+	}
+	logger.Info("Auth service initialized")
+
+	// Initialize incident tracking
+	incidentTracker := incidents.NewTracker(logger)
+	k8sClient.AddStatusListener(incidentTracker.HandleStatusChange)
+	logger.Info("Incident tracker initialized")
+
+	// Initialize revision history, starting with the existing applications
+	revisionStore := revisions.NewStore()
+	apps, err := k8sClient.GetApplications(context.Background())
+	if err != nil {
+		fatal(logger, "Failed to get applications", err)
+	}
+	for _, app := range apps {
+		revisionStore.Record(*app, revisions.ReasonCreate, "system", "", "Existing application")
+	}
+	logger.Info("Revision history initialized")
+
+	// Initialize audit logging
+	auditLogger, err := newAuditLogger(logger, cfg.Audit)
+	if err != nil {
+		fatal(logger, "Failed to create audit logger", err)
+	}
+	defer auditLogger.Close()
+	logger.Info("Audit logger initialized")
+
+	// Export application status and cache sizes
+	serverMetrics.RegisterApplications(k8sClient)
+	serverMetrics.RegisterCache("applications", func() int {
+		apps, _ := k8sClient.GetApplications(context.Background())
+		return len(apps)
+	})
+	serverMetrics.RegisterCache("incidents", incidentTracker.Len)
+	serverMetrics.RegisterCache("revisions", revisionStore.Len)
+	serverMetrics.RegisterCache("audit", auditLogger.Len)
+	serverMetrics.RegisterCache("templates", templateCatalog.Len)
+
+	// Readiness depends on the Kubernetes API server being reachable
+	checker := health.NewChecker(health.DefaultTimeout)
+	checker.AddReadinessCheck("kubernetes", k8sClient.Ping)
+
+	// Run background controllers on the elected leader only, so replicas
+	// don't do the same work twice
+	elector, err := leader.NewElector(k8sClient.Clientset(), leader.Options{
+		Enabled:   cfg.LeaderElection.Enabled,
+		Namespace: cfg.LeaderElection.Namespace,
+		LeaseName: cfg.LeaderElection.LeaseName,
+		Identity:  cfg.LeaderElection.Identity,
+	}, logger)
+	if err != nil {
+		fatal(logger, "Failed to create leader elector", err)
+	}
+	// Followers are ready by design, and the API does not depend on the
+	// lease: only a leader that cannot renew it is restarted, so its
+	// controllers stop
+	checker.AddLivenessCheck("leader-election", elector.Check)
+	checker.AddReadinessStatus("leader-election", elector.Status)
+	serverMetrics.RegisterLeader(elector.IsLeader)
+
+	// Refresh and auto-sync applications periodically on the leader
+	scheduler := autosync.NewScheduler(k8sClient, revisionStore, logger, cfg.Sync.Interval, cfg.Sync.Concurrency)
+	elector.AddController("auto-sync", scheduler.Run)
+
+	// Optional subsystems exposed by both APIs
+	apiOptions := []api.Option{
+		api.WithIncidents(incidentTracker),
+		api.WithRevisions(revisionStore),
+		api.WithAudit(auditLogger),
+		api.WithMetrics(serverMetrics),
+		api.WithLogger(logger),
+		api.WithScheduler(scheduler),
+		api.WithCatalog(templateCatalog),
+		api.WithBatch(batch.NewRunner(k8sClient, scheduler, logger)),
+	}
 
 	// Create context that listens for the interrupt signal
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// Load TLS certificates and reload them when they are rotated
+	var tlsConfig *tls.Config
+	if cfg.TLS.Enabled() {
+		reloader, err := certs.NewReloader(certs.Options{
+			CertFile:          cfg.TLS.CertFile,
+			KeyFile:           cfg.TLS.KeyFile,
+			ClientCAFile:      cfg.TLS.ClientCAFile,
+			RequireClientCert: cfg.TLS.RequireClientCert,
+		}, logger)
+		if err != nil {
+			fatal(logger, "Failed to load TLS certificates", err)
+		}
+		go reloader.Run(ctx, certs.ReloadInterval)
+		tlsConfig = reloader.TLSConfig()
+		logger.Info("TLS initialized", "clientCerts", cfg.TLS.ClientCAFile != "", "requireClientCert", cfg.TLS.RequireClientCert)
+	}
+
+	// Take part in the leader election
+	go func() {
+		if err := elector.Run(ctx); err != nil {
+			fatal(logger, "Leader election failed", err)
+		}
+	}()
+
+	// Create gRPC server, also served on the HTTP listener
+	grpcServer := newGRPCServer(logger, tlsConfig, k8sClient, authService, auditLogger, serverMetrics, apiOptions)
+
 	// Start HTTP server
-	httpServer := startHTTPServer(logger, k8sClient, authService)
-	logger.Printf("HTTP server listening on port %d", httpPort)
+	httpServer := startHTTPServer(logger, cfg, tlsConfig, grpcServer, checker, k8sClient, authService, serverMetrics, apiOptions)
+	logger.Info("HTTP server listening", "address", cfg.HTTP.Address, "tls", cfg.TLS.Enabled(), "grpc", true)
 
-	// Start gRPC server
-	grpcServer := startGRPCServer(logger, k8sClient, authService)
-	logger.Printf("gRPC server listening on port %d", grpcPort)
+	// Start a separate gRPC listener unless both share one port
+	if !cfg.SinglePort() {
+		if err := startGRPCServer(logger, cfg, grpcServer); err != nil {
+			fatal(logger, "Failed to start gRPC server", err)
+		}
+		logger.Info("gRPC server listening", "address", cfg.GRPC.Address, "tls", cfg.TLS.Enabled())
+	}
 
 	// Wait for interrupt signal
 	<-ctx.Done()
-	logger.Println("Shutdown signal received")
+	logger.Info("Shutdown signal received")
 
 	// Create a timeout context for graceful shutdown
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
 	// Shutdown HTTP server
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		logger.Printf("HTTP server shutdown error: %v", err)
+		logger.Error("HTTP server shutdown error", "error", err)
 	}
 
-	// Shutdown gRPC server
-	grpcServer.GracefulStop()
+	// Shutdown gRPC server, forcibly once the timeout has passed
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+
+	// Flush pending spans
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("Tracing shutdown error", "error", err)
+	}
 
-	logger.Println("Server shutdown complete")
+	logger.Info("Server shutdown complete")
 }
 
-func startHTTPServer(logger *log.Logger, k8sClient *kubernetes.Client, authService *auth.Service) *http.Server {
+func startHTTPServer(logger *slog.Logger, cfg *config.Config, tlsConfig *tls.Config, grpcServer *grpc.Server, checker *health.Checker, k8sClient *kubernetes.Client, authService *auth.Service, serverMetrics *metrics.Metrics, apiOptions []api.Option) *http.Server {
 	// Create REST API handler
-	apiHandler := api.NewRESTHandler(k8sClient, authService)
+	apiHandler := api.NewRESTHandler(k8sClient, authService, apiOptions...)
 
 	// Create HTTP server
 	mux := http.NewServeMux()
@@ -80,41 +263,127 @@ func startHTTPServer(logger *log.Logger, k8sClient *kubernetes.Client, authServi
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "OK")
 	})
+	mux.Handle("/livez", checker.LivezHandler())
+	mux.Handle("/readyz", checker.ReadyzHandler())
+	if cfg.Metrics.Enabled {
+		mux.Handle(cfg.Metrics.Path, serverMetrics.Handler())
+	}
+
+	// gRPC needs HTTP/2, which is negotiated with TLS or spoken directly
+	protocols := &http.Protocols{}
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
 
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", httpPort),
-		Handler: mux,
+		Addr:      cfg.HTTP.Address,
+		Handler:   grpcweb.CORS(cfg.CORS.AllowedOrigins, multiplexHandler(grpcServer, mux)),
+		TLSConfig: tlsConfig,
+		Protocols: protocols,
+		ErrorLog:  slog.NewLogLogger(logger.Handler(), slog.LevelError),
 	}
 
 	// Start HTTP server in a goroutine
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatalf("HTTP server error: %v", err)
+		var err error
+		if tlsConfig != nil {
+			// The certificates come from the TLS config
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal(logger, "HTTP server error", err)
 		}
 	}()
 
 	return server
 }
 
-func startGRPCServer(logger *log.Logger, k8sClient *kubernetes.Client, authService *auth.Service) *grpc.Server {
+// newGRPCServer creates the gRPC server with all services registered
+func newGRPCServer(logger *slog.Logger, tlsConfig *tls.Config, k8sClient *kubernetes.Client, authService *auth.Service, auditLogger *audit.Logger, serverMetrics *metrics.Metrics, apiOptions []api.Option) *grpc.Server {
+	var serverOptions []grpc.ServerOption
+	if tlsConfig != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
 	// Create gRPC server
-	server := grpc.NewServer(
-		grpc.UnaryInterceptor(auth.GRPCAuthInterceptor(authService)),
-	)
+	server := grpc.NewServer(append(serverOptions,
+		grpc.ChainUnaryInterceptor(
+			tracing.GRPCInterceptor(),
+			logging.GRPCInterceptor(logger),
+			serverMetrics.GRPCInterceptor(),
+			audit.GRPCInterceptor(auditLogger),
+			auth.GRPCAuthInterceptor(authService),
+		),
+	)...)
 
 	// Register gRPC services
-	api.RegisterGRPCServices(server, k8sClient)
+	api.RegisterGRPCServices(server, k8sClient, authService, apiOptions...)
+
+	return server
+}
+
+// startGRPCServer serves the gRPC server on its own listener
+func startGRPCServer(logger *slog.Logger, cfg *config.Config, server *grpc.Server) error {
+	lis, err := net.Listen("tcp", cfg.GRPC.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen for gRPC: %w", err)
+	}
 
 	// Start gRPC server in a goroutine
 	go func() {
-		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
-		if err != nil {
-			logger.Fatalf("Failed to listen for gRPC: %v", err)
-		}
 		if err := server.Serve(lis); err != nil {
-			logger.Fatalf("gRPC server error: %v", err)
+			fatal(logger, "gRPC server error", err)
 		}
 	}()
 
-	return server
+	return nil
+}
+
+// multiplexHandler serves native gRPC and gRPC-Web requests with grpcServer
+// and all other requests with httpHandler, so a single port serves every API
+func multiplexHandler(grpcServer *grpc.Server, httpHandler http.Handler) http.Handler {
+	grpcWebHandler := grpcweb.Handler(grpcServer)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case grpcweb.IsGRPCRequest(r):
+			grpcServer.ServeHTTP(w, r)
+		case grpcweb.IsGRPCWebRequest(r):
+			grpcWebHandler.ServeHTTP(w, r)
+		default:
+			httpHandler.ServeHTTP(w, r)
+		}
+	})
+}
+
+// fatal logs an error and exits
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// newAuditLogger creates the audit logger with the configured sinks
+func newAuditLogger(logger *slog.Logger, cfg config.AuditConfig) (*audit.Logger, error) {
+	var sinks []audit.Sink
+
+	switch path := cfg.LogFile; path {
+	case "":
+	case "-":
+		sinks = append(sinks, audit.NewWriterSink(os.Stdout))
+	default:
+		sink, err := audit.NewFileSink(path)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if url := cfg.WebhookURL; url != "" {
+		sinks = append(sinks, audit.NewWebhookSink(url, func(err error) {
+			logger.Error("Audit webhook error", "error", err)
+		}))
+	}
+
+	return audit.NewLogger(logger, auditCapacity, sinks...), nil
 }
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// syncStatuses are the sync statuses exported per application
+var syncStatuses = []kubernetes.SyncStatus{
+	kubernetes.SyncStatusSynced,
+	kubernetes.SyncStatusOutOfSync,
+	kubernetes.SyncStatusUnknown,
+}
+
+// applicationCollector exports one series per application and status, set
+// to 1 for the current status and 0 otherwise, so alerts can match e.g.
+// devops_bridge_application_health_status{status="Degraded"} == 1
+type applicationCollector struct {
+	client     *kubernetes.Client
+	healthDesc *prometheus.Desc
+	syncDesc   *prometheus.Desc
+}
+
+// newApplicationCollector creates a collector for the applications of client
+func newApplicationCollector(client *kubernetes.Client) *applicationCollector {
+	return &applicationCollector{
+		client: client,
+		healthDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "application", "health_status"),
+			"Health status of an application, 1 for the current status.",
+			[]string{"application", "namespace", "status"}, nil,
+		),
+		syncDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "application", "sync_status"),
+			"Sync status of an application, 1 for the current status.",
+			[]string{"application", "namespace", "sync_status"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *applicationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.healthDesc
+	ch <- c.syncDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *applicationCollector) Collect(ch chan<- prometheus.Metric) {
+	apps, err := c.client.GetApplications(context.Background())
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.healthDesc, err)
+		return
+	}
+
+	for _, app := range apps {
+		for _, s := range kubernetes.ApplicationStatuses {
+			ch <- prometheus.MustNewConstMetric(c.healthDesc, prometheus.GaugeValue,
+				boolValue(app.Status == s), app.Name, app.Namespace, string(s))
+		}
+		for _, s := range syncStatuses {
+			ch <- prometheus.MustNewConstMetric(c.syncDesc, prometheus.GaugeValue,
+				boolValue(app.SyncStatus == s), app.Name, app.Namespace, string(s))
+		}
+	}
+}
+
+// boolValue converts a boolean to a gauge value
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
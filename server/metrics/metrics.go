@@ -0,0 +1,215 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	k8smetrics "k8s.io/client-go/tools/metrics"
+)
+
+// namespace prefixes every metric name
+const namespace = "devops_bridge"
+
+// Metrics collects the Prometheus metrics of the server
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	grpcRequests  *prometheus.CounterVec
+	grpcDuration  *prometheus.HistogramVec
+	authFailures  *prometheus.CounterVec
+	k8sDuration   *prometheus.HistogramVec
+	k8sRequests   *prometheus.CounterVec
+	cacheObjects  *prometheus.GaugeVec
+	cacheSizeFunc map[string]func() int
+}
+
+// New creates and registers the server metrics, including the Go runtime
+// and process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of REST API requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of REST API requests by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_requests_total",
+			Help:      "Number of gRPC requests by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_request_duration_seconds",
+			Help:      "Latency of gRPC requests by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		authFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_failures_total",
+			Help:      "Number of rejected requests by transport and reason (unauthenticated or forbidden).",
+		}, []string{"transport", "reason"}),
+		k8sDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "kubernetes_request_duration_seconds",
+			Help:      "Latency of Kubernetes API requests by verb.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"verb"}),
+		k8sRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "kubernetes_requests_total",
+			Help:      "Number of Kubernetes API requests by method and status code.",
+		}, []string{"method", "code"}),
+		cacheObjects: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cache_objects",
+			Help:      "Number of objects held in the server's in-memory caches.",
+		}, []string{"cache"}),
+		cacheSizeFunc: make(map[string]func() int),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.grpcRequests,
+		m.grpcDuration,
+		m.authFailures,
+		m.k8sDuration,
+		m.k8sRequests,
+		&cacheCollector{m},
+	)
+
+	// Observe the requests made by client-go. client-go only accepts the
+	// first registration, so this must happen before any client is used.
+	k8smetrics.Register(k8smetrics.RegisterOpts{
+		RequestLatency: &k8sLatency{m.k8sDuration},
+		RequestResult:  &k8sResult{m.k8sRequests},
+	})
+
+	return m
+}
+
+// Handler returns the HTTP handler serving the metrics in the Prometheus format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTP records a served REST API request. route is the route pattern,
+// e.g. /applications/{name}, to keep the number of series bounded.
+func (m *Metrics) ObserveHTTP(route, method string, code int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(route, method, strconv.Itoa(code)).Inc()
+	m.httpDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// AuthFailure records a rejected request
+func (m *Metrics) AuthFailure(transport, reason string) {
+	m.authFailures.WithLabelValues(transport, reason).Inc()
+}
+
+// RegisterCache reports the number of objects returned by size as the size
+// of the named cache
+func (m *Metrics) RegisterCache(name string, size func() int) {
+	m.cacheSizeFunc[name] = size
+}
+
+// RegisterApplications exports the health and sync status of every
+// application of client as gauges
+func (m *Metrics) RegisterApplications(client *kubernetes.Client) {
+	m.registry.MustRegister(newApplicationCollector(client))
+}
+
+// RegisterLeader exports whether this replica is the leader that runs the
+// background controllers
+func (m *Metrics) RegisterLeader(isLeader func() bool) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "leader",
+		Help:      "1 if this replica is the leader running the background controllers, 0 otherwise.",
+	}, func() float64 {
+		if isLeader() {
+			return 1
+		}
+		return 0
+	}))
+}
+
+// GRPCInterceptor creates a gRPC interceptor that records request counts,
+// latencies and auth failures. It must be chained before
+// auth.GRPCAuthInterceptor so requests rejected by it are counted too.
+func (m *Metrics) GRPCInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		m.grpcRequests.WithLabelValues(info.FullMethod, code.String()).Inc()
+		m.grpcDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+		switch code {
+		case codes.Unauthenticated:
+			m.AuthFailure("grpc", "unauthenticated")
+		case codes.PermissionDenied:
+			m.AuthFailure("grpc", "forbidden")
+		}
+
+		return resp, err
+	}
+}
+
+// cacheCollector updates the cache size gauges when scraped
+type cacheCollector struct {
+	m *Metrics
+}
+
+// Describe implements prometheus.Collector
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.m.cacheObjects.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, size := range c.m.cacheSizeFunc {
+		c.m.cacheObjects.WithLabelValues(name).Set(float64(size()))
+	}
+	c.m.cacheObjects.Collect(ch)
+}
+
+// k8sLatency adapts a histogram to client-go's latency metric
+type k8sLatency struct {
+	histogram *prometheus.HistogramVec
+}
+
+// Observe implements k8smetrics.LatencyMetric
+func (l *k8sLatency) Observe(ctx context.Context, verb string, u url.URL, latency time.Duration) {
+	l.histogram.WithLabelValues(strings.ToUpper(verb)).Observe(latency.Seconds())
+}
+
+// k8sResult adapts a counter to client-go's result metric
+type k8sResult struct {
+	counter *prometheus.CounterVec
+}
+
+// Increment implements k8smetrics.ResultMetric
+func (r *k8sResult) Increment(ctx context.Context, code, method, host string) {
+	r.counter.WithLabelValues(method, code).Inc()
+}
@@ -0,0 +1,199 @@
+package revisions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// Reason describes what created a revision
+type Reason string
+
+const (
+	// ReasonCreate is recorded when an application is created
+	ReasonCreate Reason = "create"
+	// ReasonUpdate is recorded when an application is updated
+	ReasonUpdate Reason = "update"
+	// ReasonSync is recorded when an application is synced
+	ReasonSync Reason = "sync"
+	// ReasonRollback is recorded when an application is rolled back to a previous revision
+	ReasonRollback Reason = "rollback"
+)
+
+// ErrRevisionNotFound is returned when a revision does not exist
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Revision is an immutable snapshot of an application's spec
+type Revision struct {
+	Number      int    `json:"number"`
+	Application string `json:"application"`
+	Reason      Reason `json:"reason"`
+	Message     string `json:"message,omitempty"`
+	// Author is the ID of the user who made the change
+	Author     string    `json:"author"`
+	AuthorName string    `json:"authorName,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	// Spec is the application as it was after the change, without its
+	// observed health and sync status
+	Spec kubernetes.Application `json:"spec"`
+}
+
+// Change is a single field that differs between two revisions
+type Change struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Store keeps the revision history of every application
+type Store struct {
+	mu        sync.RWMutex
+	revisions map[string][]Revision
+	now       func() time.Time
+}
+
+// NewStore creates a new revision store
+func NewStore() *Store {
+	return &Store{
+		revisions: make(map[string][]Revision),
+		now:       time.Now,
+	}
+}
+
+// Record stores a new revision of app and returns it
+func (s *Store) Record(app kubernetes.Application, reason Reason, author, authorName, message string) Revision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Only the spec is part of a revision
+	app.Status = ""
+	app.SyncStatus = ""
+	app.TargetCommit = ""
+	app.ResourceVersion = ""
+
+	history := s.revisions[app.Name]
+	revision := Revision{
+		Number:      len(history) + 1,
+		Application: app.Name,
+		Reason:      reason,
+		Message:     message,
+		Author:      author,
+		AuthorName:  authorName,
+		CreatedAt:   s.now(),
+		Spec:        app,
+	}
+	s.revisions[app.Name] = append(history, revision)
+
+	return revision
+}
+
+// List returns the revisions of an application, newest first
+func (s *Store) List(application string) []Revision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.revisions[application]
+	result := make([]Revision, len(history))
+	for i := range history {
+		result[len(history)-1-i] = history[i]
+	}
+	return result
+}
+
+// Get returns a single revision of an application
+func (s *Store) Get(application string, number int) (Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.revisions[application]
+	if number < 1 || number > len(history) {
+		return Revision{}, ErrRevisionNotFound
+	}
+	return history[number-1], nil
+}
+
+// Latest returns the newest revision of an application
+func (s *Store) Latest(application string) (Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.revisions[application]
+	if len(history) == 0 {
+		return Revision{}, ErrRevisionNotFound
+	}
+	return history[len(history)-1], nil
+}
+
+// Len returns the number of revisions stored across all applications
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, history := range s.revisions {
+		n += len(history)
+	}
+	return n
+}
+
+// Diff returns the fields that changed between the specs of two revisions,
+// sorted by field name. Nested fields are named with dots, e.g. source.path.
+func Diff(from, to Revision) ([]Change, error) {
+	fromFields, err := flatten(from.Spec)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := flatten(to.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	for field, value := range fromFields {
+		if other, ok := toFields[field]; !ok || !reflect.DeepEqual(value, other) {
+			changes = append(changes, Change{Field: field, From: value, To: toFields[field]})
+		}
+	}
+	for field, value := range toFields {
+		if _, ok := fromFields[field]; !ok {
+			changes = append(changes, Change{Field: field, To: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes, nil
+}
+
+// flatten converts an application to a map of dotted JSON field names to values
+func flatten(app kubernetes.Application) (map[string]interface{}, error) {
+	data, err := json.Marshal(app)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode spec: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode spec: %w", err)
+	}
+
+	result := make(map[string]interface{})
+	var walk func(prefix string, value interface{})
+	walk = func(prefix string, value interface{}) {
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			for key, v := range nested {
+				walk(prefix+"."+key, v)
+			}
+			return
+		}
+		result[prefix[1:]] = value
+	}
+	for key, value := range fields {
+		walk("."+key, value)
+	}
+	return result, nil
+}
@@ -0,0 +1,244 @@
+package sources
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// ErrLocalRepo is returned for repository URLs that don't point at a remote
+// server, such as file:// URLs and paths on the server's file system
+var ErrLocalRepo = errors.New("repository URL must be a remote http(s), ssh or git URL")
+
+// Repos keeps a bare mirror of every Git repository used by an application
+// in a cache directory, so each refresh only fetches what changed
+type Repos struct {
+	dir string
+	// allowLocal lets tests check out repositories on the local file system
+	allowLocal bool
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewRepos creates a repository cache in dir, which is created if needed
+func NewRepos(dir string) *Repos {
+	return &Repos{dir: dir, locks: make(map[string]*sync.Mutex)}
+}
+
+// Checkout fetches repoURL and extracts the files of revision, a branch,
+// tag or commit, under paths into a new temporary directory, or all its
+// files if paths is empty or holds the root. It returns the directory,
+// which the caller removes, and the commit revision resolved to. Symbolic
+// links are not extracted, so manifests cannot read files outside the
+// repository.
+func (r *Repos) Checkout(ctx context.Context, repoURL, revision string, paths ...string) (dir, commit string, err error) {
+	if !r.allowLocal && !kubernetes.IsRemoteRepoURL(repoURL) {
+		return "", "", ErrLocalRepo
+	}
+	if revision == "" {
+		revision = "HEAD"
+	}
+
+	// Fetches and checkouts of the same repository don't run concurrently
+	lock := r.lock(repoURL)
+	lock.Lock()
+	defer lock.Unlock()
+
+	mirror, err := r.fetch(ctx, repoURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	out, err := git(ctx, mirror, "rev-parse", "--verify", "--end-of-options", revision+"^{commit}")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve revision %q: %w", revision, err)
+	}
+	commit = strings.TrimSpace(string(out))
+
+	dir, err = os.MkdirTemp("", "devops-bridge-checkout-")
+	if err != nil {
+		return "", "", err
+	}
+	if err := extract(ctx, mirror, commit, dir, paths); err != nil {
+		os.RemoveAll(dir)
+		return "", "", err
+	}
+	return dir, commit, nil
+}
+
+// Extract writes the files of commit under paths to dir, like Checkout, from
+// the cached mirror of repoURL, which Checkout fetched before
+func (r *Repos) Extract(ctx context.Context, repoURL, commit, dir string, paths ...string) error {
+	lock := r.lock(repoURL)
+	lock.Lock()
+	defer lock.Unlock()
+	return extract(ctx, r.mirror(repoURL), commit, dir, paths)
+}
+
+// lock returns the lock of a repository
+func (r *Repos) lock(repoURL string) *sync.Mutex {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lock, ok := r.locks[repoURL]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.locks[repoURL] = lock
+	}
+	return lock
+}
+
+// fetch clones repoURL into the cache, or fetches it if it is cached
+// already, and returns the path of the mirror
+func (r *Repos) fetch(ctx context.Context, repoURL string) (string, error) {
+	mirror := r.mirror(repoURL)
+
+	if _, err := os.Stat(mirror); err == nil {
+		if _, err := git(ctx, mirror, "fetch", "--prune", "--quiet", "origin"); err != nil {
+			return "", fmt.Errorf("failed to fetch %s: %w", repoURL, err)
+		}
+		return mirror, nil
+	}
+
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create repository cache: %w", err)
+	}
+	// Clone next to the mirror so an interrupted clone is never mistaken
+	// for a complete one
+	tmp, err := os.MkdirTemp(r.dir, "clone-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create repository cache: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	if _, err := git(ctx, "", "clone", "--mirror", "--quiet", "--", repoURL, tmp); err != nil {
+		return "", fmt.Errorf("failed to clone %s: %w", repoURL, err)
+	}
+	if err := os.Rename(tmp, mirror); err != nil {
+		return "", fmt.Errorf("failed to clone %s: %w", repoURL, err)
+	}
+	return mirror, nil
+}
+
+// mirror returns the path of the mirror of repoURL in the cache
+func (r *Repos) mirror(repoURL string) string {
+	sum := sha256.Sum256([]byte(repoURL))
+	return filepath.Join(r.dir, hex.EncodeToString(sum[:8])+".git")
+}
+
+// extract writes the files of commit under paths in the repository at
+// gitDir to dir. The archive is streamed, so large repositories are never
+// held in memory.
+func extract(ctx context.Context, gitDir, commit, dir string, paths []string) error {
+	args := []string{"archive", "--format=tar", commit}
+	if len(paths) > 0 && !slices.Contains(paths, "") {
+		args = append(append(args, "--"), paths...)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	// Stops git if the archive is not read to the end
+	defer cancel()
+	cmd, stderr := gitCommand(ctx, gitDir, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to read commit %s: %w", commit, err)
+	}
+
+	err = extractTar(stdout, dir)
+	if waitErr := cmd.Wait(); waitErr != nil && err == nil {
+		err = gitError(waitErr, stderr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read commit %s: %w", commit, err)
+	}
+	return nil
+}
+
+// extractTar writes the directories and regular files of a tar archive to
+// dir, skipping entries that would land outside it
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !filepath.IsLocal(header.Name) {
+			continue
+		}
+		path := filepath.Join(dir, header.Name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// git runs a git command on the repository at gitDir, if set, and returns
+// its output. Errors include what git printed to stderr.
+func git(ctx context.Context, gitDir string, args ...string) ([]byte, error) {
+	cmd, stderr := gitCommand(ctx, gitDir, args...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, gitError(err, stderr)
+	}
+	return out, nil
+}
+
+// gitCommand prepares a git command on the repository at gitDir, if set,
+// and returns it with the buffer its stderr is written to
+func gitCommand(ctx context.Context, gitDir string, args ...string) (*exec.Cmd, *bytes.Buffer) {
+	if gitDir != "" {
+		args = append([]string{"--git-dir", gitDir}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	// Never wait for credentials on a terminal
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	return cmd, stderr
+}
+
+// gitError adds what git printed to stderr to the error of a git command
+func gitError(err error, stderr *bytes.Buffer) error {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
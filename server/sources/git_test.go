@@ -0,0 +1,170 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+)
+
+// testRepoFiles are committed to the repository created by newTestRepo
+var testRepoFiles = map[string]string{
+	"apps/guestbook/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: guestbook
+data:
+  greeting: hello
+`,
+	"apps/api/overlays/prod/kustomization.yaml": `resources:
+- ../../base
+namePrefix: prod-
+`,
+	"apps/api/base/kustomization.yaml": `resources:
+- service.yaml
+`,
+	"apps/api/base/service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+  - port: 80
+`,
+	"other/secret.yaml": `apiVersion: v1
+kind: Secret
+metadata:
+  name: other
+`,
+}
+
+// newTestRepo creates a bare repository with testRepoFiles on its main
+// branch and returns its path and the commit of main
+func newTestRepo(t *testing.T) (repo, commit string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo = filepath.Join(t.TempDir(), "repo.git")
+	work := t.TempDir()
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	run(work, "init", "--bare", "--quiet", repo)
+	run(work, "init", "--quiet")
+	for name, content := range testRepoFiles {
+		path := filepath.Join(work, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run(work, "add", ".")
+	run(work, "commit", "--quiet", "-m", "Add applications")
+	run(work, "push", "--quiet", repo, "HEAD:refs/heads/main")
+	return repo, run(work, "rev-parse", "HEAD")
+}
+
+// newTestRepos creates a repository cache that may check out local
+// repositories
+func newTestRepos(t *testing.T) *Repos {
+	repos := NewRepos(t.TempDir())
+	repos.allowLocal = true
+	return repos
+}
+
+func TestCheckoutExtractsOnlyPaths(t *testing.T) {
+	repo, want := newTestRepo(t)
+	repos := newTestRepos(t)
+
+	dir, commit, err := repos.Checkout(context.Background(), repo, "main", "apps/guestbook")
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if commit != want {
+		t.Errorf("Checkout() commit = %s, want %s", commit, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "apps/guestbook/configmap.yaml")); err != nil {
+		t.Errorf("file under the path is not extracted: %v", err)
+	}
+	for _, name := range []string{"other/secret.yaml", "apps/api/base/service.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("file %s outside the path is extracted", name)
+		}
+	}
+
+	// A second checkout fetches into the cached mirror
+	dir, _, err = repos.Checkout(context.Background(), repo, want)
+	if err != nil {
+		t.Fatalf("Checkout() of a cached repository error = %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := os.Stat(filepath.Join(dir, "other/secret.yaml")); err != nil {
+		t.Errorf("checkout without paths does not extract every file: %v", err)
+	}
+}
+
+func TestCheckoutRejectsLocalRepos(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	repos := NewRepos(t.TempDir())
+
+	for _, repoURL := range []string{repo, "file://" + repo, "./repo.git", "ext::sh -c touch% /tmp/pwned"} {
+		if _, _, err := repos.Checkout(context.Background(), repoURL, "main"); !errors.Is(err, ErrLocalRepo) {
+			t.Errorf("Checkout(%q) error = %v, want %v", repoURL, err, ErrLocalRepo)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	repo, commit := newTestRepo(t)
+	renderer := &Renderer{repos: newTestRepos(t)}
+
+	for _, tt := range []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"manifests", "apps/guestbook", []string{"ConfigMap/guestbook"}},
+		// The base of the overlay is outside its path
+		{"kustomization", "apps/api/overlays/prod", []string{"Service/prod-api"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &kubernetes.Application{
+				Name:      "test",
+				Namespace: "default",
+				Source:    &kubernetes.ApplicationSource{RepoURL: repo, Revision: "main", Path: tt.path},
+			}
+			objects, gotCommit, err := renderer.Render(context.Background(), app)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if gotCommit != commit {
+				t.Errorf("Render() commit = %s, want %s", gotCommit, commit)
+			}
+			var got []string
+			for _, obj := range objects {
+				got = append(got, obj.GetKind()+"/"+obj.GetName())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Render() objects = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package sources
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// isChart reports whether path, within the checkout in dir, is a packaged
+// chart or a chart directory
+func isChart(dir, path string) bool {
+	if strings.HasSuffix(path, ".tgz") {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dir, path, chartutil.ChartfileName))
+	return err == nil
+}
+
+// renderChart renders the Helm chart at path within the checkout in dir the
+// way helm template does, without contacting the cluster. CRDs in the crds
+// directory come first; hooks and NOTES.txt are left out.
+func renderChart(dir, path string, app *kubernetes.Application) ([]*unstructured.Unstructured, error) {
+	chrt, err := loader.Load(filepath.Join(dir, path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
+	}
+
+	helm := app.Source.Helm
+	if helm == nil {
+		helm = &kubernetes.HelmSource{}
+	}
+	// Values files are relative to the chart, or to the directory of a
+	// packaged chart
+	base := path
+	if strings.HasSuffix(path, ".tgz") {
+		base = filepath.Dir(path)
+	}
+	values, err := chartValues(dir, base, helm)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := chartutil.ProcessDependenciesWithMerge(chrt, values); err != nil {
+		return nil, fmt.Errorf("failed to process chart dependencies: %w", err)
+	}
+	releaseName := helm.ReleaseName
+	if releaseName == "" {
+		releaseName = app.Name
+	}
+	options := chartutil.ReleaseOptions{
+		Name:      releaseName,
+		Namespace: app.Namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	renderValues, err := chartutil.ToRenderValues(chrt, values, options, chartutil.DefaultCapabilities)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chart values: %w", err)
+	}
+
+	files, err := engine.Render(chrt, renderValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render chart: %w", err)
+	}
+	for name := range files {
+		if strings.HasSuffix(name, "NOTES.txt") {
+			delete(files, name)
+		}
+	}
+	_, manifests, err := releaseutil.SortManifests(files, nil, releaseutil.InstallOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rendered chart: %w", err)
+	}
+
+	var objects []*unstructured.Unstructured
+	for _, crd := range chrt.CRDObjects() {
+		decoded, err := decodeManifests(crd.File.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", crd.Filename, err)
+		}
+		objects = append(objects, decoded...)
+	}
+	for _, manifest := range manifests {
+		decoded, err := decodeManifests([]byte(manifest.Content))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", manifest.Name, err)
+		}
+		objects = append(objects, decoded...)
+	}
+	return objects, nil
+}
+
+// chartValues merges the values files of a chart source, relative to base
+// within the checkout in dir, and then its inline values
+func chartValues(dir, base string, helm *kubernetes.HelmSource) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, file := range helm.ValueFiles {
+		path := filepath.Join(base, file)
+		if !filepath.IsLocal(path) {
+			return nil, fmt.Errorf("values file %s: %w", file, ErrInvalidPath)
+		}
+		fileValues, err := chartutil.ReadValuesFile(filepath.Join(dir, path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file %s: %w", file, err)
+		}
+		values = mergeValues(values, fileValues)
+	}
+
+	inline, err := chartutil.ReadValues([]byte(helm.Values))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse inline values: %w", err)
+	}
+	return mergeValues(values, inline), nil
+}
+
+// mergeValues merges src into dst the way helm merges values files: nested
+// maps are merged and other values in src replace those in dst
+func mergeValues(dst, src map[string]interface{}) map[string]interface{} {
+	for key, value := range src {
+		if nested, ok := value.(map[string]interface{}); ok {
+			if existing, ok := dst[key].(map[string]interface{}); ok {
+				dst[key] = mergeValues(existing, nested)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+	return dst
+}
@@ -0,0 +1,172 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+// isKustomization reports whether path, within the checkout in dir, holds a
+// kustomization file
+func isKustomization(dir, path string) bool {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		if _, err := os.Stat(filepath.Join(dir, path, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// kustomizationBases returns the local directories, relative to the
+// repository root, that the kustomization at path within the checkout in
+// dir uses as resources, components or bases. Remote bases are left to
+// Kustomize, and references leaving the repository are skipped.
+func kustomizationBases(dir, path string) ([]string, error) {
+	var data []byte
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		var err error
+		if data, err = os.ReadFile(filepath.Join(dir, path, name)); err == nil {
+			break
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if data == nil {
+		return nil, nil
+	}
+	var kustomization types.Kustomization
+	if err := yaml.Unmarshal(data, &kustomization); err != nil {
+		return nil, fmt.Errorf("failed to parse kustomization at %s: %w", path, err)
+	}
+
+	var bases []string
+	for _, ref := range slices.Concat(kustomization.Resources, kustomization.Components, kustomization.Bases) {
+		if strings.Contains(ref, "://") || strings.HasPrefix(ref, "git@") {
+			continue
+		}
+		base := filepath.Join(path, ref)
+		// Files within path are extracted already
+		if filepath.IsLocal(base) && !within(base, path) {
+			bases = append(bases, base)
+		}
+	}
+	return bases, nil
+}
+
+// renderKustomization builds the Kustomize overlay at path within the
+// checkout in dir, the way kustomize build does. Overrides of the source
+// are applied by an overlay on top of it in a directory of its own, so the
+// checkout stays untouched.
+func renderKustomization(dir, path string, source *kubernetes.KustomizeSource) ([]*unstructured.Unstructured, error) {
+	root := filepath.Join(dir, path)
+	if source != nil {
+		overlay, err := writeOverlay(root, source)
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(overlay)
+		root = overlay
+	}
+
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resources, err := kustomizer.Run(filesys.MakeFsOnDisk(), root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kustomization: %w", err)
+	}
+	data, err := resources.AsYaml()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kustomization: %w", err)
+	}
+	return decodeManifests(data)
+}
+
+// writeOverlay writes a kustomization that applies the overrides of source
+// to the kustomization in the directory base into a new temporary
+// directory, and returns it. The caller removes the directory.
+func writeOverlay(base string, source *kubernetes.KustomizeSource) (string, error) {
+	overlay, err := os.MkdirTemp("", "devops-bridge-overlay-")
+	if err != nil {
+		return "", err
+	}
+	if err := writeOverlayFile(overlay, base, source); err != nil {
+		os.RemoveAll(overlay)
+		return "", err
+	}
+	return overlay, nil
+}
+
+// writeOverlayFile writes the kustomization of writeOverlay to overlay. It
+// refers to base by a relative path, as Kustomize rejects absolute ones.
+func writeOverlayFile(overlay, base string, source *kubernetes.KustomizeSource) error {
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return err
+	}
+	ref, err := filepath.Rel(overlay, base)
+	if err != nil {
+		return err
+	}
+	kustomization := types.Kustomization{
+		TypeMeta: types.TypeMeta{
+			APIVersion: types.KustomizationVersion,
+			Kind:       types.KustomizationKind,
+		},
+		Resources:  []string{ref},
+		NamePrefix: source.NamePrefix,
+	}
+	if len(source.CommonLabels) > 0 {
+		kustomization.Labels = []types.Label{{Pairs: source.CommonLabels, IncludeSelectors: true}}
+	}
+	for _, image := range source.Images {
+		parsed, err := parseImage(image)
+		if err != nil {
+			return err
+		}
+		kustomization.Images = append(kustomization.Images, parsed)
+	}
+
+	data, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(overlay, konfig.DefaultKustomizationFileName()), data, 0o644)
+}
+
+// parseImage parses an image override in the format of kustomize edit set
+// image: name=newName:tag, name=newName@digest, name:tag or name@digest
+func parseImage(image string) (types.Image, error) {
+	name, ref, rename := strings.Cut(image, "=")
+	if !rename {
+		ref = image
+	}
+
+	var parsed types.Image
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref, parsed.Digest = ref[:i], ref[i+1:]
+	} else if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref, parsed.NewTag = ref[:i], ref[i+1:]
+	}
+	if !rename {
+		name = ref
+	} else if ref != name {
+		parsed.NewName = ref
+	}
+	parsed.Name = name
+
+	if parsed.Name == "" || (parsed.NewName == "" && parsed.NewTag == "" && parsed.Digest == "") {
+		return types.Image{}, fmt.Errorf("invalid image override %q, expected name=newName:tag, name:tag or name@digest", image)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,93 @@
+package sources
+
+import (
+	"bytes"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// kustomizeCase is the case.yaml of a directory in testdata/kustomize. The
+// directory holds the checkout the case renders and the expected output in
+// want.yaml.
+type kustomizeCase struct {
+	Path      string                      `json:"path"`
+	Kustomize *kubernetes.KustomizeSource `json:"kustomize"`
+}
+
+func TestRenderKustomizationGolden(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "kustomize", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(dir, "case.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var tc kustomizeCase
+			if err := yaml.UnmarshalStrict(data, &tc); err != nil {
+				t.Fatalf("failed to parse case.yaml: %v", err)
+			}
+
+			checkout := filepath.Join(dir, "checkout")
+			before := listFiles(t, checkout)
+			objects, err := renderKustomization(checkout, tc.Path, tc.Kustomize)
+			if err != nil {
+				t.Fatalf("renderKustomization() error = %v", err)
+			}
+			if after := listFiles(t, checkout); !slices.Equal(after, before) {
+				t.Errorf("renderKustomization() changed the checkout: files %v, want %v", after, before)
+			}
+
+			var got bytes.Buffer
+			for i, obj := range objects {
+				out, err := yaml.Marshal(obj.Object)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if i > 0 {
+					got.WriteString("---\n")
+				}
+				got.Write(out)
+			}
+
+			golden := filepath.Join(dir, "want.yaml")
+			if *update {
+				if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run the test with -update to create it)", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("renderKustomization() =\n%s\nwant (%s)\n%s", got.Bytes(), golden, want)
+			}
+		})
+	}
+}
+
+// listFiles returns the paths of every file and directory under dir
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+		files = append(files, path)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
@@ -0,0 +1,141 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/sysintelligent/devops-bridge/server/catalog"
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ErrInvalidPath is returned for source paths that leave the repository
+var ErrInvalidPath = errors.New("source path must be relative and stay within the repository")
+
+// Renderer renders the desired state of applications from the plain
+// manifests, Helm charts or Kustomize overlays in the Git repositories they
+// point at, or from catalog templates. It implements
+// kubernetes.ManifestSource.
+type Renderer struct {
+	repos     *Repos
+	templates *catalog.Catalog
+}
+
+// NewRenderer creates a renderer that caches repositories in cacheDir and
+// renders template sources from templates
+func NewRenderer(cacheDir string, templates *catalog.Catalog) *Renderer {
+	return &Renderer{repos: NewRepos(cacheDir), templates: templates}
+}
+
+// Render checks out the source revision of app and returns the objects of
+// the manifests, Helm chart or Kustomize overlay in its path, with the
+// commit they were read from. Only the path, Helm values files and the
+// bases of a Kustomize overlay are checked out. Template sources are rendered with RenderTemplate.
+func (r *Renderer) Render(ctx context.Context, app *kubernetes.Application) ([]*unstructured.Unstructured, string, error) {
+	source := app.Source
+	if source != nil && source.Template != nil {
+		return RenderTemplate(r.templates, app)
+	}
+	if source == nil || source.RepoURL == "" {
+		return nil, "", errors.New("application has no source repository")
+	}
+	if err := ValidatePath(source.Path); err != nil {
+		return nil, "", err
+	}
+
+	dir, commit, err := r.repos.Checkout(ctx, source.RepoURL, source.Revision, checkoutPaths(source)...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(dir)
+	if source.Helm == nil && isKustomization(dir, source.Path) {
+		if err := r.extractBases(ctx, source.RepoURL, commit, dir, source.Path); err != nil {
+			return nil, "", fmt.Errorf("failed to read kustomization bases at %s: %w", commit, err)
+		}
+	}
+
+	var objects []*unstructured.Unstructured
+	switch {
+	case source.Helm != nil && source.Kustomize != nil:
+		err = errors.New("a source cannot be both a Helm chart and a Kustomize overlay")
+	case isChart(dir, source.Path):
+		objects, err = renderChart(dir, source.Path, app)
+	case source.Helm != nil:
+		err = errors.New("no Helm chart at source path")
+	case isKustomization(dir, source.Path):
+		objects, err = renderKustomization(dir, source.Path, source.Kustomize)
+	case source.Kustomize != nil:
+		err = errors.New("no kustomization at source path")
+	default:
+		objects, err = readManifests(filepath.Join(dir, source.Path))
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read manifests at %s: %w", commit, err)
+	}
+	return objects, commit, nil
+}
+
+// extractBases extracts the directories outside path that the
+// kustomization at path refers to, and theirs in turn, into the checkout in
+// dir
+func (r *Renderer) extractBases(ctx context.Context, repoURL, commit, dir, path string) error {
+	extracted := []string{path}
+	pending := []string{path}
+	for len(pending) > 0 {
+		kustomization := pending[0]
+		pending = pending[1:]
+		bases, err := kustomizationBases(dir, kustomization)
+		if err != nil {
+			return err
+		}
+		var missing []string
+		for _, base := range bases {
+			if !slices.ContainsFunc(extracted, func(p string) bool { return within(base, p) }) {
+				missing = append(missing, base)
+				extracted = append(extracted, base)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		if err := r.repos.Extract(ctx, repoURL, commit, dir, missing...); err != nil {
+			return err
+		}
+		pending = append(pending, missing...)
+	}
+	return nil
+}
+
+// within reports whether path is dir or inside it, both relative to the
+// repository root
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// checkoutPaths returns the paths of the repository that rendering source
+// reads: its path and its Helm values files
+func checkoutPaths(source *kubernetes.ApplicationSource) []string {
+	paths := []string{source.Path}
+	if source.Helm != nil {
+		for _, file := range source.Helm.ValueFiles {
+			if path := filepath.Join(source.Path, file); filepath.IsLocal(path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// ValidatePath checks that a source path is within the repository. An
+// empty path is the repository root.
+func ValidatePath(path string) error {
+	if path != "" && !filepath.IsLocal(path) {
+		return ErrInvalidPath
+	}
+	return nil
+}
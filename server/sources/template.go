@@ -0,0 +1,46 @@
+package sources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/sysintelligent/devops-bridge/server/catalog"
+	"github.com/sysintelligent/devops-bridge/server/kubernetes"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CheckSource renders the template source of app, so that applications with
+// invalid parameters are rejected when they are stored. Repository sources
+// are checked when they are refreshed, as that fetches them.
+func (r *Renderer) CheckSource(app *kubernetes.Application) error {
+	if app.Source == nil || app.Source.Template == nil {
+		return nil
+	}
+	_, _, err := RenderTemplate(r.templates, app)
+	return err
+}
+
+// RenderTemplate renders the objects of an application with a template
+// source from templates. In place of a commit it returns a digest of the
+// rendered manifests, which changes with the template and the parameters.
+func RenderTemplate(templates *catalog.Catalog, app *kubernetes.Application) ([]*unstructured.Unstructured, string, error) {
+	if templates == nil {
+		return nil, "", errors.New("no template catalog configured")
+	}
+	source := app.Source
+	if source.RepoURL != "" || source.Helm != nil || source.Kustomize != nil {
+		return nil, "", errors.New("a source cannot be both a template and a repository")
+	}
+
+	data, err := templates.Render(app)
+	if err != nil {
+		return nil, "", err
+	}
+	objects, err := decodeManifests(data)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(data)
+	return objects, hex.EncodeToString(sum[:20]), nil
+}